@@ -0,0 +1,257 @@
+package aoc
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type AnswerKind int
+
+const (
+	IntKind AnswerKind = iota
+	StringKind
+	BigKind
+	ImageKind
+)
+
+// Answer is the answer to one part of a puzzle: a number, however large, some
+// text, or a picture made of pixels which spells something out.
+type Answer struct {
+	kind  AnswerKind
+	n     int
+	text  string
+	big   *big.Int
+	image []string // rows of '#' and '.'
+}
+
+var jsonOutput = flag.Bool("json", false, "print answers as JSON, one per line")
+
+var kindNames = [...]string{"int", "string", "big", "image"}
+
+func IntAnswer(n int) Answer {
+	return Answer{kind: IntKind, n: n}
+}
+
+func StringAnswer(text string) Answer {
+	return Answer{kind: StringKind, text: text}
+}
+
+func BigAnswer(n *big.Int) Answer {
+	return Answer{kind: BigKind, big: new(big.Int).Set(n)}
+}
+
+// ImageAnswer takes rows of pixels, where '#' or '█' is lit and anything else
+// is not.
+func ImageAnswer(rows []string) Answer {
+	image := make([]string, len(rows))
+	for i, row := range rows {
+		image[i] = strings.Map(func(c rune) rune {
+			if c == '#' || c == '█' {
+				return '#'
+			}
+			return '.'
+		}, row)
+	}
+	return Answer{kind: ImageKind, image: image}
+}
+
+// NewAnswer wraps whatever a part returned.
+func NewAnswer(value any) Answer {
+	switch v := value.(type) {
+	case Answer:
+		return v
+	case int:
+		return IntAnswer(v)
+	case string:
+		return StringAnswer(v)
+	case *big.Int:
+		return BigAnswer(v)
+	default:
+		panic(fmt.Sprintf("can't make an answer from %T", value))
+	}
+}
+
+// ParseAnswer reads an answer back from its canonical form.
+func ParseAnswer(text string) Answer {
+	if rows := strings.Split(text, "\n"); len(rows) > 1 && isImage(rows) {
+		return ImageAnswer(rows)
+	}
+	if n, err := strconv.Atoi(text); err == nil {
+		return IntAnswer(n)
+	}
+	if n, ok := new(big.Int).SetString(text, 10); ok {
+		return BigAnswer(n)
+	}
+	return StringAnswer(text)
+}
+
+func isImage(rows []string) bool {
+	for _, row := range rows {
+		if strings.Trim(row, "#.") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (this AnswerKind) String() string {
+	return kindNames[this]
+}
+
+func (this Answer) Kind() AnswerKind {
+	return this.kind
+}
+
+func (this Answer) Image() []string {
+	return this.image
+}
+
+// String is the canonical form: numbers in decimal, text as it is, and
+// images as rows of '#' and '.' separated by newlines.
+func (this Answer) String() string {
+	switch this.kind {
+	case IntKind:
+		return strconv.Itoa(this.n)
+	case BigKind:
+		return this.big.String()
+	case ImageKind:
+		return strings.Join(this.image, "\n")
+	default:
+		return this.text
+	}
+}
+
+// Line is the canonical form squeezed onto one line, with the rows of an
+// image separated by '/'.
+func (this Answer) Line() string {
+	if this.kind == ImageKind {
+		return strings.Join(this.image, "/")
+	}
+	return this.String()
+}
+
+// Equal compares numbers by value, whether or not they are big, and
+// everything else by its canonical form.
+func (this Answer) Equal(that Answer) bool {
+	if this.isNumber() && that.isNumber() {
+		return this.bigValue().Cmp(that.bigValue()) == 0
+	}
+	return this.kind == that.kind && this.String() == that.String()
+}
+
+func (this Answer) isNumber() bool {
+	return this.kind == IntKind || this.kind == BigKind
+}
+
+func (this Answer) bigValue() *big.Int {
+	if this.kind == BigKind {
+		return this.big
+	}
+	return big.NewInt(int64(this.n))
+}
+
+type answerJSON struct {
+	Kind  string          `json:"kind"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Answers are encoded as {"kind": ..., "value": ...}. Big numbers are
+// strings, so nothing reading them loses precision, and images are arrays
+// of rows.
+func (this Answer) MarshalJSON() ([]byte, error) {
+	var value any
+	switch this.kind {
+	case IntKind:
+		value = this.n
+	case BigKind:
+		value = this.big.String()
+	case ImageKind:
+		value = this.image
+	default:
+		value = this.text
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(answerJSON{this.kind.String(), raw})
+}
+
+func (this *Answer) UnmarshalJSON(data []byte) error {
+	var encoded answerJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+
+	switch encoded.Kind {
+	case "int":
+		var n int
+		if err := json.Unmarshal(encoded.Value, &n); err != nil {
+			return err
+		}
+		*this = IntAnswer(n)
+	case "string":
+		var text string
+		if err := json.Unmarshal(encoded.Value, &text); err != nil {
+			return err
+		}
+		*this = StringAnswer(text)
+	case "big":
+		var text string
+		if err := json.Unmarshal(encoded.Value, &text); err != nil {
+			return err
+		}
+		n, ok := new(big.Int).SetString(text, 10)
+		if !ok {
+			return fmt.Errorf("bad big number %q", text)
+		}
+		*this = BigAnswer(n)
+	case "image":
+		var rows []string
+		if err := json.Unmarshal(encoded.Value, &rows); err != nil {
+			return err
+		}
+		*this = ImageAnswer(rows)
+	default:
+		return fmt.Errorf("unknown kind of answer %q", encoded.Kind)
+	}
+	return nil
+}
+
+// PartAnswer is how PrintAnswer writes an answer as JSON.
+type PartAnswer struct {
+	Part   int    `json:"part"`
+	Answer Answer `json:"answer"`
+}
+
+// PrintAnswer prints the answer to a part in its canonical form, or as a
+// line of JSON if the -json flag was given.
+func PrintAnswer(part int, value any) {
+	CheckErr(writeAnswer(os.Stdout, part, value))
+}
+
+func writeAnswer(w io.Writer, part int, value any) error {
+	answer := NewAnswer(value)
+	if *jsonOutput {
+		return json.NewEncoder(w).Encode(PartAnswer{part, answer})
+	}
+	_, err := fmt.Fprintln(w, answer)
+	return err
+}
+
+// RefuseJSON stops the program if the -json flag was given. Modes which
+// print something other than answers call it, as anything reading the JSON,
+// such as aoc serve and aoc batch, would choke on what they print.
+func RefuseJSON(mode string) {
+	if *jsonOutput {
+		log.Fatalf("%s prints no answers, so can't be used with -json", mode)
+	}
+}
@@ -0,0 +1,91 @@
+package aoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+var huge, _ = new(big.Int).SetString("123456789012345678901234567890", 10)
+
+var answerTests = []struct {
+	value any
+	plain string
+	json  string
+}{
+	{42, "42\n", `{"part":1,"answer":{"kind":"int","value":42}}` + "\n"},
+	{-7, "-7\n", `{"part":1,"answer":{"kind":"int","value":-7}}` + "\n"},
+	{"CMZ", "CMZ\n", `{"part":1,"answer":{"kind":"string","value":"CMZ"}}` + "\n"},
+	{"", "\n", `{"part":1,"answer":{"kind":"string","value":""}}` + "\n"},
+	{huge, "123456789012345678901234567890\n", `{"part":1,"answer":{"kind":"big","value":"123456789012345678901234567890"}}` + "\n"},
+	{ImageAnswer([]string{"#.█", " ##"}), "#.#\n.##\n", `{"part":1,"answer":{"kind":"image","value":["#.#",".##"]}}` + "\n"},
+}
+
+func withJSON(t *testing.T, on bool) {
+	saved := *jsonOutput
+	*jsonOutput = on
+	t.Cleanup(func() { *jsonOutput = saved })
+}
+
+func TestPrintAnswerPlain(t *testing.T) {
+	withJSON(t, false)
+	for _, test := range answerTests {
+		var b bytes.Buffer
+		if err := writeAnswer(&b, 1, test.value); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != test.plain {
+			t.Errorf("answer %v printed as %q, want %q", test.value, b.String(), test.plain)
+		}
+	}
+}
+
+// In JSON mode every answer is one line, which reads back as the answer
+// that was printed.
+func TestPrintAnswerJSON(t *testing.T) {
+	withJSON(t, true)
+	var b bytes.Buffer
+	for _, test := range answerTests {
+		start := b.Len()
+		if err := writeAnswer(&b, 1, test.value); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String()[start:]; got != test.json {
+			t.Errorf("answer %v printed as %s, want %s", test.value, got, test.json)
+		}
+	}
+
+	decoder := json.NewDecoder(&b)
+	for _, test := range answerTests {
+		var read PartAnswer
+		if err := decoder.Decode(&read); err != nil {
+			t.Fatal(err)
+		}
+		if want := NewAnswer(test.value); read.Part != 1 || !read.Answer.Equal(want) {
+			t.Errorf("read back part %d answer %v, want part 1 answer %v", read.Part, read.Answer, want)
+		}
+	}
+	if decoder.More() {
+		t.Error("more printed than the answers")
+	}
+}
+
+func TestAnswerEqual(t *testing.T) {
+	tests := []struct {
+		a, b  Answer
+		equal bool
+	}{
+		{IntAnswer(5), BigAnswer(big.NewInt(5)), true},
+		{IntAnswer(5), StringAnswer("5"), false},
+		{IntAnswer(5), IntAnswer(6), false},
+		{ParseAnswer("#.\n.#"), ImageAnswer([]string{"#.", ".#"}), true},
+		{ParseAnswer("123456789012345678901234567890"), BigAnswer(huge), true},
+		{ParseAnswer("CMZ"), StringAnswer("CMZ"), true},
+	}
+	for _, test := range tests {
+		if got := test.a.Equal(test.b); got != test.equal {
+			t.Errorf("%v (%v) equal to %v (%v) = %v, want %v", test.a, test.a.Kind(), test.b, test.b.Kind(), got, test.equal)
+		}
+	}
+}
@@ -2,17 +2,21 @@ package aoc
 
 import (
 	"bufio"
+	"flag"
 	"log"
 	"os"
 	"strconv"
 )
 
+// GetFilename parses the command line, so any flags a day declares must be
+// declared before it is called.
 func GetFilename() string {
-	if len(os.Args) != 2 {
-		log.Fatal("usage: ", os.Args[0], " input-file")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: ", os.Args[0], " [flags] input-file")
 	}
 
-	return os.Args[1]
+	return flag.Arg(0)
 }
 
 func GetInputLines(filename string) []string {
@@ -0,0 +1,224 @@
+package batch
+
+import (
+	"advent-of-code/aoc"
+	"advent-of-code/aoc/serve"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Result is the outcome of running a day on one input.
+type Result struct {
+	Input    string
+	Answers  map[int]aoc.Answer
+	Duration time.Duration
+	Err      error
+}
+
+type Group struct {
+	Name    string
+	Results []Result
+}
+
+// Inputs expands each pattern, which may be a glob or a directory of .txt
+// files, to the files it names, in order and without duplicates. A pattern matching
+// nothing is an error, as it's most likely a typo.
+func Inputs(patterns []string) ([]string, error) {
+	inputs := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		glob := pattern
+		if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+			glob = filepath.Join(pattern, "*.txt")
+		}
+		matches, err := filepath.Glob(glob)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%s: no inputs found", pattern)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				inputs = append(inputs, match)
+			}
+		}
+	}
+	return inputs, nil
+}
+
+// Run runs the program on each input, using up to workers at once. Each run
+// is limited to timeout, if that is non-zero. The results are in the same
+// order as the inputs.
+func Run(ctx context.Context, program serve.Program, inputs []string, workers int, timeout time.Duration) []Result {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]Result, len(inputs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = runOne(ctx, program, inputs[i], timeout)
+			}
+		}()
+	}
+
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func runOne(ctx context.Context, program serve.Program, input string, timeout time.Duration) Result {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	answers, err := program.Answers(ctx, input)
+	result := Result{Input: input, Answers: answers, Duration: time.Since(start)}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Err = fmt.Errorf("no answer within %v", timeout)
+	} else if err != nil {
+		result.Err = err
+	}
+	return result
+}
+
+func (this Result) Failed() bool {
+	return this.Err != nil
+}
+
+// Answer is the answer to a part on one line, or nothing if there isn't one.
+func (this Result) Answer(part int) string {
+	if answer, found := this.Answers[part]; found {
+		return answer.Line()
+	}
+	return ""
+}
+
+// Sort orders results by "input" name or running "time", slowest first.
+func Sort(results []Result, by string) error {
+	switch by {
+	case "input":
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Input < results[j].Input
+		})
+	case "time":
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Duration > results[j].Duration
+		})
+	default:
+		return fmt.Errorf("can't sort by %q; use input or time", by)
+	}
+	return nil
+}
+
+// GroupBy splits results by "status", failures first, by the "dir" holding
+// the input, or not at all for "none". Results keep their order within each
+// group.
+func GroupBy(results []Result, by string) ([]Group, error) {
+	var key func(Result) string
+	switch by {
+	case "status":
+		key = func(result Result) string {
+			if result.Failed() {
+				return "failed"
+			}
+			return "ok"
+		}
+	case "dir":
+		key = func(result Result) string {
+			return filepath.Dir(result.Input)
+		}
+	case "none":
+		key = func(Result) string {
+			return ""
+		}
+	default:
+		return nil, fmt.Errorf("can't group by %q; use status, dir or none", by)
+	}
+
+	groups := make([]Group, 0)
+	index := make(map[string]int)
+	for _, result := range results {
+		name := key(result)
+		i, found := index[name]
+		if !found {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, Group{Name: name})
+		}
+		groups[i].Results = append(groups[i].Results, result)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if by == "status" {
+			return groups[i].Name == "failed" && groups[j].Name != "failed"
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups, nil
+}
+
+// WriteTable prints the groups as a table, with a summary at the end.
+func WriteTable(w io.Writer, groups []Group) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "INPUT\tPART 1\tPART 2\tTIME\tERROR")
+
+	total, failed := 0, 0
+	for i, group := range groups {
+		if group.Name != "" {
+			if i > 0 {
+				fmt.Fprintln(tw, "\t\t\t\t")
+			}
+			fmt.Fprintf(tw, "[%s]\t\t\t\t\n", group.Name)
+		}
+		for _, result := range group.Results {
+			message := ""
+			if result.Failed() {
+				message = firstLine(result.Err.Error())
+				failed++
+			}
+			total++
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%v\t%s\n", result.Input, result.Answer(1), result.Answer(2), result.Duration.Round(time.Millisecond), message)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d inputs, %d failed\n", total, failed)
+	return err
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " ..."
+	}
+	return s
+}
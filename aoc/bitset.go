@@ -0,0 +1,234 @@
+package aoc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// BitSet is a set of non-negative ints. Sets whose members are all below 64
+// live in a single word; setting a larger member moves the set onto a
+// growable slice of words.
+//
+// Set and Clear modify the set in place. Everything else returns a new set,
+// so a BitSet can be copied and extended without affecting the original.
+type BitSet struct {
+	small uint64
+	large []uint64 // nil while every member fits in small
+}
+
+// BitSetKey is a comparable value identifying the members of a BitSet,
+// suitable for use as (part of) a map key.
+type BitSetKey struct {
+	small uint64
+	large string
+}
+
+func NewBitSet(members ...int) BitSet {
+	var set BitSet
+	for _, i := range members {
+		set.Set(i)
+	}
+	return set
+}
+
+func (this *BitSet) Set(i int) {
+	if this.large == nil && i < 64 {
+		this.small |= 1 << i
+		return
+	}
+	this.grow(i/64 + 1)
+	this.large[i/64] |= 1 << (i % 64)
+}
+
+func (this *BitSet) Clear(i int) {
+	if this.large == nil {
+		if i < 64 {
+			this.small &^= 1 << i
+		}
+		return
+	}
+	if i/64 < len(this.large) {
+		this.large[i/64] &^= 1 << (i % 64)
+	}
+}
+
+func (this BitSet) Has(i int) bool {
+	if this.large == nil {
+		return i < 64 && this.small&(1<<i) != 0
+	}
+	return i/64 < len(this.large) && this.large[i/64]&(1<<(i%64)) != 0
+}
+
+func (this BitSet) With(i int) BitSet {
+	set := this.Clone()
+	set.Set(i)
+	return set
+}
+
+func (this BitSet) Without(i int) BitSet {
+	set := this.Clone()
+	set.Clear(i)
+	return set
+}
+
+func (this BitSet) Clone() BitSet {
+	if this.large == nil {
+		return this
+	}
+	large := make([]uint64, len(this.large))
+	copy(large, this.large)
+	return BitSet{large: large}
+}
+
+func (this BitSet) Union(that BitSet) BitSet {
+	if this.large == nil && that.large == nil {
+		return BitSet{small: this.small | that.small}
+	}
+	return combine(this, that, func(a, b uint64) uint64 { return a | b })
+}
+
+func (this BitSet) Intersect(that BitSet) BitSet {
+	if this.large == nil || that.large == nil {
+		return BitSet{small: this.word(0) & that.word(0)}
+	}
+	return combine(this, that, func(a, b uint64) uint64 { return a & b })
+}
+
+func (this BitSet) Difference(that BitSet) BitSet {
+	if this.large == nil {
+		return BitSet{small: this.small &^ that.word(0)}
+	}
+	return combine(this, that, func(a, b uint64) uint64 { return a &^ b })
+}
+
+func (this BitSet) SymmetricDifference(that BitSet) BitSet {
+	if this.large == nil && that.large == nil {
+		return BitSet{small: this.small ^ that.small}
+	}
+	return combine(this, that, func(a, b uint64) uint64 { return a ^ b })
+}
+
+func (this BitSet) Count() int {
+	if this.large == nil {
+		return bits.OnesCount64(this.small)
+	}
+	count := 0
+	for _, word := range this.large {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+func (this BitSet) IsEmpty() bool {
+	return this.Count() == 0
+}
+
+func (this BitSet) IsSubsetOf(that BitSet) bool {
+	return this.Difference(that).IsEmpty()
+}
+
+func (this BitSet) Equal(that BitSet) bool {
+	return this.Key() == that.Key()
+}
+
+// Next returns the smallest member >= i, if any.
+func (this BitSet) Next(i int) (int, bool) {
+	for w := i / 64; w < this.words(); w++ {
+		word := this.word(w)
+		if w == i/64 {
+			word &= ^uint64(0) << (i % 64)
+		}
+		if word != 0 {
+			return w*64 + bits.TrailingZeros64(word), true
+		}
+	}
+	return 0, false
+}
+
+// Each calls fn with every member in ascending order.
+func (this BitSet) Each(fn func(int)) {
+	for w := 0; w < this.words(); w++ {
+		for word := this.word(w); word != 0; word &= word - 1 {
+			fn(w*64 + bits.TrailingZeros64(word))
+		}
+	}
+}
+
+func (this BitSet) Members() []int {
+	members := make([]int, 0, this.Count())
+	this.Each(func(i int) {
+		members = append(members, i)
+	})
+	return members
+}
+
+// Key is the same for any two sets with the same members, however they
+// happen to be stored.
+func (this BitSet) Key() BitSetKey {
+	n := this.words()
+	for n > 1 && this.word(n-1) == 0 {
+		n--
+	}
+	if n <= 1 {
+		return BitSetKey{small: this.word(0)}
+	}
+
+	buf := make([]byte, 8*n)
+	for w := 0; w < n; w++ {
+		binary.LittleEndian.PutUint64(buf[8*w:], this.word(w))
+	}
+	return BitSetKey{large: string(buf)}
+}
+
+func (this BitSet) String() string {
+	members := make([]string, 0, this.Count())
+	this.Each(func(i int) {
+		members = append(members, fmt.Sprint(i))
+	})
+	return "{" + strings.Join(members, ", ") + "}"
+}
+
+func (this BitSet) words() int {
+	if this.large == nil {
+		return 1
+	}
+	return len(this.large)
+}
+
+func (this BitSet) word(w int) uint64 {
+	if this.large == nil {
+		if w == 0 {
+			return this.small
+		}
+		return 0
+	}
+	if w < len(this.large) {
+		return this.large[w]
+	}
+	return 0
+}
+
+func (this *BitSet) grow(words int) {
+	if this.large == nil {
+		this.large = make([]uint64, words)
+		this.large[0] = this.small
+		this.small = 0
+	}
+	for len(this.large) < words {
+		this.large = append(this.large, 0)
+	}
+}
+
+func combine(a, b BitSet, op func(a, b uint64) uint64) BitSet {
+	n := a.words()
+	if b.words() > n {
+		n = b.words()
+	}
+	large := make([]uint64, n)
+	for w := range large {
+		large[w] = op(a.word(w), b.word(w))
+	}
+	return BitSet{large: large}
+}
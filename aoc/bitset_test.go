@@ -0,0 +1,176 @@
+package aoc
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// mapSet is the map-based set BitSet replaces.
+type mapSet map[int]bool
+
+func (this mapSet) union(that mapSet) mapSet {
+	set := make(mapSet, len(this)+len(that))
+	for i := range this {
+		set[i] = true
+	}
+	for i := range that {
+		set[i] = true
+	}
+	return set
+}
+
+func randomMembers(n, limit int, seed int64) []int {
+	random := rand.New(rand.NewSource(seed))
+	members := make([]int, n)
+	for i := range members {
+		members[i] = random.Intn(limit)
+	}
+	return members
+}
+
+func TestBitSetMatchesMapSet(t *testing.T) {
+	for _, limit := range []int{64, 1000} {
+		a, b := randomMembers(50, limit, 1), randomMembers(50, limit, 2)
+		bits := NewBitSet(a...).Union(NewBitSet(b...))
+		set := make(mapSet)
+		for _, i := range append(a, b...) {
+			set[i] = true
+		}
+
+		if bits.Count() != len(set) {
+			t.Errorf("limit %d: Count() = %d, want %d", limit, bits.Count(), len(set))
+		}
+		for i := 0; i < limit; i++ {
+			if bits.Has(i) != set[i] {
+				t.Errorf("limit %d: Has(%d) = %v, want %v", limit, i, bits.Has(i), set[i])
+			}
+		}
+	}
+}
+
+// The sizes cover the single word fast path and the growable one.
+var benchmarkSizes = []struct {
+	name  string
+	limit int
+}{
+	{"small", 64},
+	{"large", 1024},
+}
+
+func BenchmarkSetHas(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		members := randomMembers(32, size.limit, 1)
+
+		b.Run(size.name+"/bitset", func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				var set BitSet
+				for _, i := range members {
+					set.Set(i)
+				}
+				for _, i := range members {
+					if !set.Has(i) {
+						b.Fatal("missing member")
+					}
+				}
+			}
+		})
+		b.Run(size.name+"/map", func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				set := make(mapSet)
+				for _, i := range members {
+					set[i] = true
+				}
+				for _, i := range members {
+					if !set[i] {
+						b.Fatal("missing member")
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSetUnion(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		x, y := randomMembers(32, size.limit, 1), randomMembers(32, size.limit, 2)
+
+		bitsX, bitsY := NewBitSet(x...), NewBitSet(y...)
+		b.Run(size.name+"/bitset", func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				bitsX.Union(bitsY)
+			}
+		})
+
+		mapX, mapY := make(mapSet), make(mapSet)
+		for _, i := range x {
+			mapX[i] = true
+		}
+		for _, i := range y {
+			mapY[i] = true
+		}
+		b.Run(size.name+"/map", func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				mapX.union(mapY)
+			}
+		})
+	}
+}
+
+func BenchmarkSetCount(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		members := randomMembers(32, size.limit, 1)
+
+		bits := NewBitSet(members...)
+		b.Run(size.name+"/bitset", func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				bits.Count()
+			}
+		})
+
+		set := make(mapSet)
+		for _, i := range members {
+			set[i] = true
+		}
+		b.Run(size.name+"/map", func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				count := 0
+				for range set {
+					count++
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSetAsKey remembers sets as day16 does with its visited states.
+// A map can't be a key itself, so it has to be turned into a sorted list.
+func BenchmarkSetAsKey(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		members := randomMembers(32, size.limit, 1)
+
+		bits := NewBitSet(members...)
+		b.Run(size.name+"/bitset", func(b *testing.B) {
+			seen := make(map[BitSetKey]bool)
+			for n := 0; n < b.N; n++ {
+				seen[bits.Key()] = true
+			}
+		})
+
+		set := make(mapSet)
+		for _, i := range members {
+			set[i] = true
+		}
+		b.Run(size.name+"/map", func(b *testing.B) {
+			seen := make(map[string]bool)
+			for n := 0; n < b.N; n++ {
+				key := make([]byte, 0, 2*len(set))
+				for i := 0; i < size.limit; i++ {
+					if set[i] {
+						key = append(key, byte(i), byte(i>>8))
+					}
+				}
+				seen[string(key)] = true
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package checkpoint
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+)
+
+const DefaultInterval = time.Minute
+
+// How long after an interrupt to wait for Tick to save progress, before
+// exiting without it.
+const interruptGrace = 5 * time.Second
+
+// Checkpointer saves the progress of a long-running loop to a file every so
+// often, and when the process is interrupted, so that a later run can
+// resume from it. A Checkpointer with no path does nothing.
+//
+// An interrupt is saved at the next Tick. If there isn't one soon, or there
+// is a second interrupt, the process exits without saving.
+type Checkpointer struct {
+	path        string
+	id          string
+	interval    time.Duration
+	lastSave    time.Time
+	interrupt   chan os.Signal
+	interrupted chan struct{} // closed on the first interrupt
+	done        chan struct{}
+}
+
+// header guards against resuming from progress made on something else.
+type header struct {
+	ID string
+}
+
+// Identify builds an id from everything the saved progress depends on, such
+// as the input and the part being solved.
+func Identify(parts ...any) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprint(parts...))))
+}
+
+// New creates a checkpointer saving to prefix-name.gob. An empty prefix
+// disables checkpointing.
+func New(prefix, name, id string, interval time.Duration) *Checkpointer {
+	if prefix == "" {
+		return &Checkpointer{}
+	}
+
+	this := &Checkpointer{
+		path:        prefix + "-" + name + ".gob",
+		id:          id,
+		interval:    interval,
+		lastSave:    time.Now(),
+		interrupt:   make(chan os.Signal, 1),
+		interrupted: make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	signal.Notify(this.interrupt, os.Interrupt)
+	go this.watch()
+	return this
+}
+
+// watch makes sure an interrupt stops the process, even when Tick isn't
+// being called.
+func (this *Checkpointer) watch() {
+	select {
+	case <-this.interrupt:
+	case <-this.done:
+		return
+	}
+	close(this.interrupted)
+
+	select {
+	case <-this.interrupt:
+		log.Printf("interrupted again; progress not saved")
+	case <-time.After(interruptGrace):
+		log.Printf("interrupted, and no chance to save progress")
+	case <-this.done:
+		return
+	}
+	os.Exit(130)
+}
+
+func (this *Checkpointer) Enabled() bool {
+	return this.path != ""
+}
+
+// Load reads saved progress into value, reporting whether there was any.
+// Progress saved for a different id is ignored.
+func (this *Checkpointer) Load(value any) (bool, error) {
+	if !this.Enabled() {
+		return false, nil
+	}
+
+	file, err := os.Open(this.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	decoder := gob.NewDecoder(file)
+	var h header
+	if err := decoder.Decode(&h); err != nil {
+		return false, fmt.Errorf("%s: %w", this.path, err)
+	}
+	if h.ID != this.id {
+		log.Printf("%s: saved for a different input, ignoring", this.path)
+		return false, nil
+	}
+	if err := decoder.Decode(value); err != nil {
+		return false, fmt.Errorf("%s: %w", this.path, err)
+	}
+	log.Printf("resuming from %s", this.path)
+	return true, nil
+}
+
+// Tick saves the progress returned by save if the interval has passed. If
+// the process has been interrupted it saves regardless, then exits.
+func (this *Checkpointer) Tick(save func() any) {
+	if !this.Enabled() {
+		return
+	}
+
+	select {
+	case <-this.interrupted:
+		if err := this.Save(save()); err != nil {
+			log.Fatalf("interrupted, and couldn't save progress: %v", err)
+		}
+		log.Printf("interrupted; progress saved to %s", this.path)
+		os.Exit(130)
+	default:
+	}
+
+	if time.Since(this.lastSave) >= this.interval {
+		if err := this.Save(save()); err != nil {
+			log.Printf("couldn't save progress: %v", err)
+		}
+	}
+}
+
+// Save writes the progress now. The file is replaced atomically, so an
+// interruption while saving leaves the previous checkpoint intact.
+func (this *Checkpointer) Save(value any) error {
+	if !this.Enabled() {
+		return nil
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(this.path), filepath.Base(this.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	encoder := gob.NewEncoder(temp)
+	err = encoder.Encode(header{this.id})
+	if err == nil {
+		err = encoder.Encode(value)
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	this.lastSave = time.Now()
+	return os.Rename(temp.Name(), this.path)
+}
+
+// Done removes the checkpoint once the work it guards is finished, and
+// restores the default handling of interrupts.
+func (this *Checkpointer) Done() {
+	if !this.Enabled() {
+		return
+	}
+	signal.Stop(this.interrupt)
+	close(this.done)
+	if err := os.Remove(this.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("couldn't remove %s: %v", this.path, err)
+	}
+}
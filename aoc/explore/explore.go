@@ -0,0 +1,279 @@
+package explore
+
+import (
+	"advent-of-code/aoc/sim"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// World is a simulation which can be drawn. Cells are addressed in screen
+// order, so y increases down the screen.
+type World[S any] interface {
+	sim.Stepper
+	sim.Snapshotter[S]
+
+	// Bounds is the inclusive range of cells worth drawing.
+	Bounds() (minX, minY, maxX, maxY int)
+	Cell(x, y int) rune
+	Status() string
+}
+
+// Condition reports whether a run should stop, given any numbers typed
+// after its name.
+type Condition func(args []int) bool
+
+type condition struct {
+	help  string
+	until Condition
+}
+
+// Explorer steps a world back and forth under the control of commands typed
+// at the terminal.
+type Explorer[S any] struct {
+	world      World[S]
+	recording  *sim.Recording[S]
+	step       int
+	stable     bool // the last recorded step changed nothing
+	conditions map[string]condition
+
+	panX, panY    int // view offset from the top left of the bounds
+	zoom          int // world cells per screen cell, each way
+	width, height int
+	message       string
+}
+
+// How far "until" will run before giving up.
+const untilLimit = 1_000_000
+
+const help = `commands:
+  n [k]        step forward k steps (default 1); an empty line does n 1
+  p [k]        step back k steps
+  g N          go to step N
+  u NAME [..]  run until a condition holds
+  w a s d [k]  pan up, left, down, right by k cells (default half a screen)
+  + -          zoom in, out
+  c            centre the view on the top left of the world again
+  size W H     set the screen size
+  q            quit`
+
+func New[S any](world World[S]) *Explorer[S] {
+	this := &Explorer[S]{
+		world:      world,
+		recording:  sim.Record[S](world, 0),
+		conditions: make(map[string]condition),
+		zoom:       1,
+		width:      envInt("COLUMNS", 80),
+		height:     envInt("LINES", 24) - 3,
+	}
+	this.AddCondition("stable", "until a step changes nothing", func([]int) bool {
+		return false // running stops anyway once stable
+	})
+	return this
+}
+
+func (this *Explorer[S]) AddCondition(name, help string, until Condition) {
+	this.conditions[name] = condition{help, until}
+}
+
+// Run reads commands until q or the end of the input.
+func (this *Explorer[S]) Run(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		this.draw(out)
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		if quit := this.command(strings.Fields(scanner.Text())); quit {
+			return nil
+		}
+	}
+}
+
+func (this *Explorer[S]) command(words []string) bool {
+	this.message = ""
+	if len(words) == 0 {
+		words = []string{"n"}
+	}
+
+	if words[0] == "u" {
+		if len(words) < 2 {
+			this.message = "until what?"
+		} else {
+			this.until(words[1], words[2:])
+		}
+		return false
+	}
+
+	args, err := parseArgs(words[1:])
+	if err != nil {
+		this.message = err.Error()
+		return false
+	}
+	arg := func(defaultValue int) int {
+		if len(args) > 0 {
+			return args[0]
+		}
+		return defaultValue
+	}
+
+	switch words[0] {
+	case "q":
+		return true
+	case "n":
+		this.goTo(this.step + arg(1))
+	case "p":
+		this.goTo(this.step - arg(1))
+	case "g":
+		this.goTo(arg(this.step))
+	case "w":
+		this.panY -= arg(this.height/2) * this.zoom
+	case "s":
+		this.panY += arg(this.height/2) * this.zoom
+	case "a":
+		this.panX -= arg(this.width/2) * this.zoom
+	case "d":
+		this.panX += arg(this.width/2) * this.zoom
+	case "+":
+		if this.zoom > 1 {
+			this.zoom /= 2
+		}
+	case "-":
+		this.zoom *= 2
+	case "c":
+		this.panX, this.panY = 0, 0
+	case "size":
+		if len(args) == 2 && args[0] > 0 && args[1] > 0 {
+			this.width, this.height = args[0], args[1]
+		}
+	default:
+		this.message = help + this.conditionHelp()
+	}
+	return false
+}
+
+// goTo moves to the given step, recording new steps as needed.
+func (this *Explorer[S]) goTo(step int) {
+	if step < 0 {
+		step = 0
+	}
+	last := this.recording.Len() - 1
+	if step > last {
+		this.recording.Replay(this.world, last)
+		for last < step && !this.stable {
+			this.stable = !this.world.Step()
+			this.recording.Append(this.world)
+			last++
+		}
+		if step > last {
+			this.message = "stable: nothing changes after this step"
+			step = last
+		}
+	}
+	this.recording.Replay(this.world, step)
+	this.step = step
+}
+
+func (this *Explorer[S]) until(name string, words []string) {
+	condition, found := this.conditions[name]
+	if !found {
+		this.message = fmt.Sprintf("no condition %q", name) + this.conditionHelp()
+		return
+	}
+	args, err := parseArgs(words)
+	if err != nil {
+		this.message = err.Error()
+		return
+	}
+
+	for i := 0; i < untilLimit; i++ {
+		before := this.step
+		this.goTo(this.step + 1)
+		if this.step == before || condition.until(args) {
+			return
+		}
+	}
+	this.message = fmt.Sprintf("gave up after %d steps", untilLimit)
+}
+
+func (this *Explorer[S]) conditionHelp() string {
+	names := make([]string, 0, len(this.conditions))
+	for name := range this.conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	help := "\nconditions:"
+	for _, name := range names {
+		help += fmt.Sprintf("\n  %-12s %s", name, this.conditions[name].help)
+	}
+	return help
+}
+
+func (this *Explorer[S]) draw(out io.Writer) {
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	fmt.Fprint(w, "\x1b[H\x1b[J")
+
+	minX, minY, maxX, maxY := this.world.Bounds()
+	originX, originY := minX+this.panX, minY+this.panY
+
+	for row := 0; row < this.height; row++ {
+		y := originY + row*this.zoom
+		if y > maxY {
+			break
+		}
+		for col := 0; col < this.width; col++ {
+			x := originX + col*this.zoom
+			if x > maxX {
+				break
+			}
+			w.WriteRune(this.block(x, y))
+		}
+		w.WriteByte('\n')
+	}
+
+	fmt.Fprintf(w, "step %d/%d  view %d,%d  zoom 1:%d  %s\n", this.step, this.recording.Len()-1, originX, originY, this.zoom, this.world.Status())
+	if this.message != "" {
+		fmt.Fprintln(w, this.message)
+	}
+	fmt.Fprint(w, "> ")
+}
+
+// block picks what to show for a zoomed-out screen cell: anything other than
+// blank space wins.
+func (this *Explorer[S]) block(x, y int) rune {
+	first := this.world.Cell(x, y)
+	for dy := 0; dy < this.zoom; dy++ {
+		for dx := 0; dx < this.zoom; dx++ {
+			if c := this.world.Cell(x+dx, y+dy); c != ' ' && c != '.' {
+				return c
+			}
+		}
+	}
+	return first
+}
+
+func parseArgs(words []string) ([]int, error) {
+	args := make([]int, len(words))
+	for i, word := range words {
+		n, err := strconv.Atoi(word)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", word)
+		}
+		args[i] = n
+	}
+	return args, nil
+}
+
+func envInt(name string, defaultValue int) int {
+	if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n > 0 {
+		return n
+	}
+	return defaultValue
+}
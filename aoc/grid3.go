@@ -0,0 +1,205 @@
+package aoc
+
+import (
+	"fmt"
+	"math"
+)
+
+type Vec3 [3]int
+
+// Grid3 is a dense box of cells, addressed by coordinates from its min to
+// its max corner inclusive.
+type Grid3[T any] struct {
+	min, size Vec3
+	cell      []T
+}
+
+// Bounds3 finds the smallest box containing all the points. With no points
+// the box is empty, its max one less than its min on every axis.
+func Bounds3(points []Vec3) (min, max Vec3) {
+	if len(points) == 0 {
+		return Vec3{}, Vec3{-1, -1, -1}
+	}
+	min = Vec3{math.MaxInt, math.MaxInt, math.MaxInt}
+	max = Vec3{math.MinInt, math.MinInt, math.MinInt}
+	for _, p := range points {
+		for axis, value := range p {
+			if value < min[axis] {
+				min[axis] = value
+			}
+			if value > max[axis] {
+				max[axis] = value
+			}
+		}
+	}
+	return min, max
+}
+
+// NewGrid3 makes a grid from min to max inclusive. If max is below min on
+// any axis the grid is empty.
+func NewGrid3[T any](min, max Vec3, defaultValue T) *Grid3[T] {
+	grid := Grid3[T]{min: min}
+	for axis := range min {
+		grid.size[axis] = max[axis] - min[axis] + 1
+		if grid.size[axis] < 0 {
+			grid.size[axis] = 0
+		}
+	}
+	grid.cell = make([]T, grid.size[0]*grid.size[1]*grid.size[2])
+	for i := range grid.cell {
+		grid.cell[i] = defaultValue
+	}
+	return &grid
+}
+
+func (this Vec3) Add(that Vec3) Vec3 {
+	return Vec3{this[0] + that[0], this[1] + that[1], this[2] + that[2]}
+}
+
+// Neighbours6 are the points sharing a face with this one.
+func (this Vec3) Neighbours6() [6]Vec3 {
+	var n [6]Vec3
+	for axis := range this {
+		n[2*axis], n[2*axis+1] = this, this
+		n[2*axis][axis]--
+		n[2*axis+1][axis]++
+	}
+	return n
+}
+
+// Neighbours26 are the points sharing a face, edge or corner with this one.
+func (this Vec3) Neighbours26() [26]Vec3 {
+	var n [26]Vec3
+	i := 0
+	for dz := -1; dz <= 1; dz++ {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dx != 0 || dy != 0 || dz != 0 {
+					n[i] = this.Add(Vec3{dx, dy, dz})
+					i++
+				}
+			}
+		}
+	}
+	return n
+}
+
+func (this Grid3[T]) Min() Vec3 {
+	return this.min
+}
+
+func (this Grid3[T]) Max() Vec3 {
+	return this.min.Add(this.size).Add(Vec3{-1, -1, -1})
+}
+
+func (this Grid3[T]) Size() Vec3 {
+	return this.size
+}
+
+func (this Grid3[T]) Contains(p Vec3) bool {
+	for axis, value := range p {
+		if value < this.min[axis] || value >= this.min[axis]+this.size[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+// Get and Set panic if the point is outside the grid.
+func (this Grid3[T]) Get(p Vec3) T {
+	return this.cell[this.offset(p)]
+}
+
+func (this *Grid3[T]) Set(p Vec3, value T) {
+	this.cell[this.offset(p)] = value
+}
+
+func (this Grid3[T]) GetMaybe(p Vec3) (T, bool) {
+	if this.Contains(p) {
+		return this.cell[this.offset(p)], true
+	}
+	var nothing T
+	return nothing, false
+}
+
+// Each visits every cell, x varying fastest.
+func (this Grid3[T]) Each(visit func(p Vec3, value T)) {
+	i := 0
+	for z := 0; z < this.size[2]; z++ {
+		for y := 0; y < this.size[1]; y++ {
+			for x := 0; x < this.size[0]; x++ {
+				visit(this.min.Add(Vec3{x, y, z}), this.cell[i])
+				i++
+			}
+		}
+	}
+}
+
+// Slice copies the plane where the given axis has the given value. The
+// remaining axes become x and y of the result, in order, counted from the
+// grid's min corner. It panics if the plane is outside the grid.
+func (this Grid3[T]) Slice(axis, value int) *Grid[T] {
+	if axis < 0 || axis > 2 {
+		panic(fmt.Sprintf("Slice: no axis %d", axis))
+	}
+	if value < this.min[axis] || value >= this.min[axis]+this.size[axis] {
+		panic(fmt.Sprintf("Slice: %d is outside the grid on axis %d", value, axis))
+	}
+	u, v := (axis+1)%3, (axis+2)%3
+	if u > v {
+		u, v = v, u
+	}
+
+	var nothing T
+	plane := NewGrid(this.size[u], this.size[v], nothing)
+	for j := 0; j < this.size[v]; j++ {
+		for i := 0; i < this.size[u]; i++ {
+			p := this.min
+			p[axis] = value
+			p[u] += i
+			p[v] += j
+			plane.Set(i, j, this.Get(p))
+		}
+	}
+	return plane
+}
+
+// FloodFill visits every cell reachable from start through faces, moving
+// only into cells which canEnter allows. It keeps its own stack rather than
+// recursing, so large regions are fine.
+func (this Grid3[T]) FloodFill(start Vec3, canEnter func(p Vec3, value T) bool, visit func(p Vec3)) {
+	if !this.Contains(start) || !canEnter(start, this.Get(start)) {
+		return
+	}
+
+	seen := make([]bool, len(this.cell))
+	seen[this.offset(start)] = true
+	stack := []Vec3{start}
+
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		visit(p)
+
+		for _, n := range p.Neighbours6() {
+			if !this.Contains(n) {
+				continue
+			}
+			i := this.offset(n)
+			if !seen[i] && canEnter(n, this.cell[i]) {
+				seen[i] = true
+				stack = append(stack, n)
+			}
+		}
+	}
+}
+
+func (this Grid3[T]) offset(p Vec3) int {
+	if !this.Contains(p) {
+		panic(fmt.Sprintf("%v is outside the grid from %v to %v", p, this.Min(), this.Max()))
+	}
+	x := p[0] - this.min[0]
+	y := p[1] - this.min[1]
+	z := p[2] - this.min[2]
+	return (z*this.size[1]+y)*this.size[0] + x
+}
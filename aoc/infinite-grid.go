@@ -66,3 +66,7 @@ func NewInfiniteGrid[T any](defaultValue T) *InfiniteGrid[T] {
 		cell: make(map[coord]T),
 	}
 }
+
+func (this InfiniteGrid[T]) Bounds() (minX, minY, maxX, maxY int) {
+	return this.min.x, this.min.y, this.max.x, this.max.y
+}
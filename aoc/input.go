@@ -0,0 +1,147 @@
+package aoc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Normalisation is a set of rules applied to a puzzle input as it is loaded.
+// Each day declares the rules it wants, so a solver never has to care
+// whether the input was saved with a BOM, CRLF line endings or stray
+// whitespace.
+type Normalisation uint
+
+const (
+	StripBOM Normalisation = 1 << iota
+	ConvertCRLF
+	TrimTrailingSpace // trailing whitespace on every line
+	TrimTrailingLines // blank lines at the end of the input
+	EnsureFinalNewline
+	StripFinalNewline
+
+	NoNormalisation Normalisation = 0
+	Normalise                     = StripBOM | ConvertCRLF | TrimTrailingSpace | TrimTrailingLines
+)
+
+const bom = "\uFEFF"
+
+// InputChanges records what normalisation actually did to an input.
+type InputChanges struct {
+	BOM                 bool
+	CRLF                int // line endings converted
+	TrailingSpace       int // lines which had trailing whitespace removed
+	TrailingLines       int // blank lines removed from the end
+	AddedFinalNewline   bool
+	RemovedFinalNewline bool
+}
+
+func NormaliseInput(input string, rules Normalisation) (string, InputChanges) {
+	var changes InputChanges
+
+	if rules&StripBOM != 0 && strings.HasPrefix(input, bom) {
+		input = input[len(bom):]
+		changes.BOM = true
+	}
+
+	if rules&ConvertCRLF != 0 {
+		changes.CRLF = strings.Count(input, "\r\n")
+		input = strings.ReplaceAll(input, "\r\n", "\n")
+	}
+
+	if rules&TrimTrailingSpace != 0 {
+		lines := strings.Split(input, "\n")
+		for i, line := range lines {
+			trimmed := strings.TrimRight(line, " \t\r\v\f")
+			if trimmed != line {
+				lines[i] = trimmed
+				changes.TrailingSpace++
+			}
+		}
+		input = strings.Join(lines, "\n")
+	}
+
+	if rules&TrimTrailingLines != 0 {
+		hadNewline := strings.HasSuffix(input, "\n")
+		trimmed := strings.TrimRight(input, "\n")
+		blank := len(input) - len(trimmed)
+		if hadNewline {
+			blank-- // the final newline is not a blank line
+		}
+		if blank > 0 {
+			changes.TrailingLines = blank
+			input = trimmed
+			if hadNewline {
+				input += "\n"
+			}
+		}
+	}
+
+	if rules&EnsureFinalNewline != 0 && input != "" && !strings.HasSuffix(input, "\n") {
+		input += "\n"
+		changes.AddedFinalNewline = true
+	}
+
+	if rules&StripFinalNewline != 0 && strings.HasSuffix(input, "\n") {
+		input = strings.TrimSuffix(input, "\n")
+		changes.RemovedFinalNewline = true
+	}
+
+	return input, changes
+}
+
+// SlurpNormalised reads the whole file and applies the given rules.
+func SlurpNormalised(filename string, rules Normalisation) (string, InputChanges) {
+	return NormaliseInput(Slurp(filename), rules)
+}
+
+// GetNormalisedLines reads the file as lines after applying the given rules.
+// As with GetInputLines, a final newline does not produce an empty last line.
+func GetNormalisedLines(filename string, rules Normalisation) ([]string, InputChanges) {
+	input, changes := SlurpNormalised(filename, rules&^StripFinalNewline)
+	input = strings.TrimSuffix(input, "\n")
+	if input == "" {
+		return []string{}, changes
+	}
+	return strings.Split(input, "\n"), changes
+}
+
+func (this InputChanges) Changed() bool {
+	return this != InputChanges{}
+}
+
+func (this InputChanges) String() string {
+	changes := make([]string, 0)
+	if this.BOM {
+		changes = append(changes, "stripped BOM")
+	}
+	if this.CRLF > 0 {
+		changes = append(changes, fmt.Sprintf("converted %d CRLF", this.CRLF))
+	}
+	if this.TrailingSpace > 0 {
+		changes = append(changes, fmt.Sprintf("trimmed trailing space from %d lines", this.TrailingSpace))
+	}
+	if this.TrailingLines > 0 {
+		changes = append(changes, fmt.Sprintf("removed %d trailing blank lines", this.TrailingLines))
+	}
+	if this.AddedFinalNewline {
+		changes = append(changes, "added final newline")
+	}
+	if this.RemovedFinalNewline {
+		changes = append(changes, "removed final newline")
+	}
+	if len(changes) == 0 {
+		return "unchanged"
+	}
+	return strings.Join(changes, ", ")
+}
+
+// ReportInputChanges notes on stderr anything normalisation changed, other
+// than the final newline which is expected to come and go.
+func ReportInputChanges(filename string, changes InputChanges) {
+	changes.AddedFinalNewline = false
+	changes.RemovedFinalNewline = false
+	if changes.Changed() {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", filename, changes)
+	}
+}
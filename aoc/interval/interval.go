@@ -0,0 +1,220 @@
+package interval
+
+import (
+	"advent-of-code/aoc/mathx"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Interval is the integers from Lo to Hi inclusive. It is empty if Lo > Hi.
+type Interval struct {
+	Lo, Hi int
+}
+
+// IntervalSet is a set of integers, held as the fewest intervals that cover
+// it: sorted, non-empty, and neither overlapping nor touching.
+type IntervalSet struct {
+	intervals []Interval
+}
+
+var Empty = Interval{0, -1}
+
+func New(lo, hi int) Interval {
+	return Interval{lo, hi}
+}
+
+func (this Interval) IsEmpty() bool {
+	return this.Lo > this.Hi
+}
+
+func (this Interval) Len() int {
+	if this.IsEmpty() {
+		return 0
+	}
+	return this.Hi - this.Lo + 1
+}
+
+func (this Interval) Contains(x int) bool {
+	return x >= this.Lo && x <= this.Hi
+}
+
+// ContainsInterval reports whether all of that is within this. Everything
+// contains the empty interval.
+func (this Interval) ContainsInterval(that Interval) bool {
+	return that.IsEmpty() || (this.Lo <= that.Lo && that.Hi <= this.Hi)
+}
+
+func (this Interval) Overlaps(that Interval) bool {
+	return !this.Intersect(that).IsEmpty()
+}
+
+func (this Interval) Intersect(that Interval) Interval {
+	return Interval{mathx.Max(this.Lo, that.Lo), mathx.Min(this.Hi, that.Hi)}
+}
+
+func (this Interval) String() string {
+	if this.IsEmpty() {
+		return "[]"
+	}
+	return fmt.Sprintf("[%d, %d]", this.Lo, this.Hi)
+}
+
+// NewSet builds a set from any number of intervals. Sorting them first
+// makes this much quicker than inserting them one at a time.
+func NewSet(intervals ...Interval) IntervalSet {
+	sorted := append([]Interval{}, intervals...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Lo < sorted[j].Lo
+	})
+
+	var this IntervalSet
+	for _, i := range sorted {
+		if i.IsEmpty() {
+			continue
+		}
+		last := len(this.intervals) - 1
+		if last >= 0 && i.Lo <= this.intervals[last].Hi+1 {
+			this.intervals[last].Hi = mathx.Max(this.intervals[last].Hi, i.Hi)
+		} else {
+			this.intervals = append(this.intervals, i)
+		}
+	}
+	return this
+}
+
+// Intervals returns the intervals making up the set, in order.
+func (this IntervalSet) Intervals() []Interval {
+	return append([]Interval{}, this.intervals...)
+}
+
+func (this IntervalSet) IsEmpty() bool {
+	return len(this.intervals) == 0
+}
+
+// Len is how many integers are in the set.
+func (this IntervalSet) Len() int {
+	total := 0
+	for _, i := range this.intervals {
+		total += i.Len()
+	}
+	return total
+}
+
+// Find returns the interval of the set containing x.
+func (this IntervalSet) Find(x int) (Interval, bool) {
+	n := sort.Search(len(this.intervals), func(k int) bool {
+		return this.intervals[k].Hi >= x
+	})
+	if n < len(this.intervals) && this.intervals[n].Contains(x) {
+		return this.intervals[n], true
+	}
+	return Interval{}, false
+}
+
+func (this IntervalSet) Contains(x int) bool {
+	_, found := this.Find(x)
+	return found
+}
+
+// Insert adds the interval, merging it with any it overlaps or touches.
+func (this *IntervalSet) Insert(i Interval) {
+	if i.IsEmpty() {
+		return
+	}
+
+	// The first interval which could merge with i, and the first after it
+	// which can't.
+	start := sort.Search(len(this.intervals), func(k int) bool {
+		return this.intervals[k].Hi >= i.Lo-1
+	})
+	end := start
+	for end < len(this.intervals) && this.intervals[end].Lo <= i.Hi+1 {
+		i.Lo = mathx.Min(i.Lo, this.intervals[end].Lo)
+		i.Hi = mathx.Max(i.Hi, this.intervals[end].Hi)
+		end++
+	}
+
+	merged := make([]Interval, 0, len(this.intervals)-(end-start)+1)
+	merged = append(merged, this.intervals[:start]...)
+	merged = append(merged, i)
+	merged = append(merged, this.intervals[end:]...)
+	this.intervals = merged
+}
+
+// Remove takes the interval out of the set, splitting any interval it falls
+// inside.
+func (this *IntervalSet) Remove(i Interval) {
+	this.intervals = this.Subtract(NewSet(i)).intervals
+}
+
+func (this IntervalSet) Union(that IntervalSet) IntervalSet {
+	result := IntervalSet{this.Intervals()}
+	for _, i := range that.intervals {
+		result.Insert(i)
+	}
+	return result
+}
+
+func (this IntervalSet) Intersect(that IntervalSet) IntervalSet {
+	var result IntervalSet
+	a, b := this.intervals, that.intervals
+	for len(a) > 0 && len(b) > 0 {
+		if overlap := a[0].Intersect(b[0]); !overlap.IsEmpty() {
+			result.intervals = append(result.intervals, overlap)
+		}
+		if a[0].Hi < b[0].Hi {
+			a = a[1:]
+		} else {
+			b = b[1:]
+		}
+	}
+	return result
+}
+
+// Subtract returns the integers in this set but not that one.
+func (this IntervalSet) Subtract(that IntervalSet) IntervalSet {
+	var result IntervalSet
+	b := that.intervals
+	for _, i := range this.intervals {
+		// Skip what ends before i, then cut out what overlaps it.
+		for len(b) > 0 && b[0].Hi < i.Lo {
+			b = b[1:]
+		}
+		for k := 0; k < len(b) && b[k].Lo <= i.Hi; k++ {
+			if b[k].Lo > i.Lo {
+				result.intervals = append(result.intervals, Interval{i.Lo, b[k].Lo - 1})
+			}
+			i.Lo = b[k].Hi + 1
+		}
+		if !i.IsEmpty() {
+			result.intervals = append(result.intervals, i)
+		}
+	}
+	return result
+}
+
+// Complement returns the integers within bounds which aren't in the set.
+func (this IntervalSet) Complement(bounds Interval) IntervalSet {
+	return NewSet(bounds).Subtract(this)
+}
+
+func (this IntervalSet) Equal(that IntervalSet) bool {
+	if len(this.intervals) != len(that.intervals) {
+		return false
+	}
+	for k := range this.intervals {
+		if this.intervals[k] != that.intervals[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func (this IntervalSet) String() string {
+	parts := make([]string, len(this.intervals))
+	for k, i := range this.intervals {
+		parts[k] = i.String()
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
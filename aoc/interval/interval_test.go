@@ -0,0 +1,264 @@
+package interval
+
+import (
+	"reflect"
+	"testing"
+)
+
+// set builds a set by inserting the intervals one at a time, so that Insert
+// is what the tests exercise rather than NewSet.
+func set(intervals ...Interval) IntervalSet {
+	var result IntervalSet
+	for _, i := range intervals {
+		result.Insert(i)
+	}
+	return result
+}
+
+func TestInterval(t *testing.T) {
+	tests := []struct {
+		i        Interval
+		empty    bool
+		length   int
+		contains []int
+		excludes []int
+	}{
+		{New(1, 3), false, 3, []int{1, 2, 3}, []int{0, 4}},
+		{New(-2, -2), false, 1, []int{-2}, []int{-3, -1}},
+		{New(5, 4), true, 0, nil, []int{4, 5}},
+		{Empty, true, 0, nil, []int{0, 1, -1}},
+	}
+	for _, test := range tests {
+		if got := test.i.IsEmpty(); got != test.empty {
+			t.Errorf("%v.IsEmpty() = %v, want %v", test.i, got, test.empty)
+		}
+		if got := test.i.Len(); got != test.length {
+			t.Errorf("%v.Len() = %d, want %d", test.i, got, test.length)
+		}
+		for _, x := range test.contains {
+			if !test.i.Contains(x) {
+				t.Errorf("%v doesn't contain %d", test.i, x)
+			}
+		}
+		for _, x := range test.excludes {
+			if test.i.Contains(x) {
+				t.Errorf("%v contains %d", test.i, x)
+			}
+		}
+	}
+}
+
+func TestIntervalPairs(t *testing.T) {
+	tests := []struct {
+		a, b      Interval
+		overlaps  bool
+		intersect Interval
+		contains  bool // a contains b
+	}{
+		{New(1, 5), New(2, 3), true, New(2, 3), true},
+		{New(1, 5), New(4, 8), true, New(4, 5), false},
+		{New(1, 5), New(5, 8), true, New(5, 5), false},
+		{New(1, 5), New(6, 8), false, Empty, false},
+		{New(6, 8), New(1, 5), false, Empty, false},
+		{New(1, 5), Empty, false, Empty, true},
+		{Empty, New(1, 5), false, Empty, false},
+	}
+	for _, test := range tests {
+		if got := test.a.Overlaps(test.b); got != test.overlaps {
+			t.Errorf("%v.Overlaps(%v) = %v, want %v", test.a, test.b, got, test.overlaps)
+		}
+		if got := test.a.Intersect(test.b); got.IsEmpty() != test.intersect.IsEmpty() || !got.IsEmpty() && got != test.intersect {
+			t.Errorf("%v.Intersect(%v) = %v, want %v", test.a, test.b, got, test.intersect)
+		}
+		if got := test.a.ContainsInterval(test.b); got != test.contains {
+			t.Errorf("%v.ContainsInterval(%v) = %v, want %v", test.a, test.b, got, test.contains)
+		}
+	}
+}
+
+func TestInsert(t *testing.T) {
+	tests := []struct {
+		name   string
+		insert []Interval
+		want   []Interval
+	}{
+		{"nothing", nil, nil},
+		{"empty interval", []Interval{Empty, New(3, 2)}, nil},
+		{"one", []Interval{New(1, 3)}, []Interval{New(1, 3)}},
+		{"apart", []Interval{New(5, 6), New(1, 2)}, []Interval{New(1, 2), New(5, 6)}},
+		{"adjacent after", []Interval{New(1, 2), New(3, 4)}, []Interval{New(1, 4)}},
+		{"adjacent before", []Interval{New(3, 4), New(1, 2)}, []Interval{New(1, 4)}},
+		{"overlapping", []Interval{New(1, 5), New(3, 8)}, []Interval{New(1, 8)}},
+		{"contained", []Interval{New(1, 10), New(3, 4)}, []Interval{New(1, 10)}},
+		{"containing", []Interval{New(3, 4), New(1, 10)}, []Interval{New(1, 10)}},
+		{"same", []Interval{New(1, 2), New(1, 2)}, []Interval{New(1, 2)}},
+		{"bridging", []Interval{New(1, 2), New(6, 7), New(3, 5)}, []Interval{New(1, 7)}},
+		{"across several", []Interval{New(1, 1), New(3, 3), New(5, 5), New(9, 9), New(2, 6)}, []Interval{New(1, 6), New(9, 9)}},
+		{"in a gap", []Interval{New(1, 2), New(8, 9), New(5, 5)}, []Interval{New(1, 2), New(5, 5), New(8, 9)}},
+		{"negative", []Interval{New(-5, -3), New(-2, 0)}, []Interval{New(-5, 0)}},
+	}
+	for _, test := range tests {
+		got := set(test.insert...)
+		if !reflect.DeepEqual(got.Intervals(), IntervalSet{test.want}.Intervals()) {
+			t.Errorf("%s: Insert gives %v, want %v", test.name, got, IntervalSet{test.want})
+		}
+		if built := NewSet(test.insert...); !built.Equal(got) {
+			t.Errorf("%s: NewSet gives %v, want %v", test.name, built, got)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   IntervalSet
+		remove Interval
+		want   IntervalSet
+	}{
+		{"from empty", IntervalSet{}, New(1, 5), IntervalSet{}},
+		{"empty interval", set(New(1, 5)), Empty, set(New(1, 5))},
+		{"all", set(New(1, 5)), New(1, 5), IntervalSet{}},
+		{"more than all", set(New(1, 5)), New(0, 9), IntervalSet{}},
+		{"middle", set(New(1, 5)), New(3, 3), set(New(1, 2), New(4, 5))},
+		{"start", set(New(1, 5)), New(0, 2), set(New(3, 5))},
+		{"end", set(New(1, 5)), New(4, 7), set(New(1, 3))},
+		{"outside", set(New(1, 5)), New(7, 9), set(New(1, 5))},
+		{"gap", set(New(1, 2), New(6, 7)), New(3, 5), set(New(1, 2), New(6, 7))},
+		{"across several", set(New(1, 2), New(4, 5), New(7, 9)), New(2, 7), set(New(1, 1), New(8, 9))},
+	}
+	for _, test := range tests {
+		got := IntervalSet{test.from.Intervals()}
+		got.Remove(test.remove)
+		if !got.Equal(test.want) {
+			t.Errorf("%s: %v without %v = %v, want %v", test.name, test.from, test.remove, got, test.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	s := set(New(1, 3), New(7, 7), New(10, 12))
+	tests := []struct {
+		x     int
+		found bool
+		in    Interval
+	}{
+		{0, false, Interval{}},
+		{1, true, New(1, 3)},
+		{3, true, New(1, 3)},
+		{4, false, Interval{}},
+		{7, true, New(7, 7)},
+		{11, true, New(10, 12)},
+		{13, false, Interval{}},
+	}
+	for _, test := range tests {
+		in, found := s.Find(test.x)
+		if found != test.found || in != test.in {
+			t.Errorf("Find(%d) = %v, %v; want %v, %v", test.x, in, found, test.in, test.found)
+		}
+		if s.Contains(test.x) != test.found {
+			t.Errorf("Contains(%d) = %v, want %v", test.x, !test.found, test.found)
+		}
+	}
+
+	if (IntervalSet{}).Contains(0) {
+		t.Error("the empty set contains 0")
+	}
+}
+
+func TestLen(t *testing.T) {
+	tests := []struct {
+		s      IntervalSet
+		length int
+		empty  bool
+	}{
+		{IntervalSet{}, 0, true},
+		{set(Empty), 0, true},
+		{set(New(4, 4)), 1, false},
+		{set(New(1, 3), New(7, 7), New(10, 12)), 7, false},
+		{set(New(1, 5), New(3, 8)), 8, false},
+	}
+	for _, test := range tests {
+		if got := test.s.Len(); got != test.length {
+			t.Errorf("%v.Len() = %d, want %d", test.s, got, test.length)
+		}
+		if got := test.s.IsEmpty(); got != test.empty {
+			t.Errorf("%v.IsEmpty() = %v, want %v", test.s, got, test.empty)
+		}
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	tests := []struct {
+		a, b                           IntervalSet
+		union, intersection, remainder IntervalSet
+	}{
+		{
+			IntervalSet{}, IntervalSet{},
+			IntervalSet{}, IntervalSet{}, IntervalSet{},
+		},
+		{
+			set(New(1, 5)), IntervalSet{},
+			set(New(1, 5)), IntervalSet{}, set(New(1, 5)),
+		},
+		{
+			IntervalSet{}, set(New(1, 5)),
+			set(New(1, 5)), IntervalSet{}, IntervalSet{},
+		},
+		{
+			set(New(1, 5)), set(New(6, 9)),
+			set(New(1, 9)), IntervalSet{}, set(New(1, 5)),
+		},
+		{
+			set(New(1, 5), New(10, 15)), set(New(4, 11)),
+			set(New(1, 15)), set(New(4, 5), New(10, 11)), set(New(1, 3), New(12, 15)),
+		},
+		{
+			set(New(0, 20)), set(New(2, 3), New(8, 9)),
+			set(New(0, 20)), set(New(2, 3), New(8, 9)), set(New(0, 1), New(4, 7), New(10, 20)),
+		},
+	}
+	for _, test := range tests {
+		if got := test.a.Union(test.b); !got.Equal(test.union) {
+			t.Errorf("%v ∪ %v = %v, want %v", test.a, test.b, got, test.union)
+		}
+		if got := test.a.Intersect(test.b); !got.Equal(test.intersection) {
+			t.Errorf("%v ∩ %v = %v, want %v", test.a, test.b, got, test.intersection)
+		}
+		if got := test.a.Subtract(test.b); !got.Equal(test.remainder) {
+			t.Errorf("%v - %v = %v, want %v", test.a, test.b, got, test.remainder)
+		}
+	}
+}
+
+func TestComplement(t *testing.T) {
+	tests := []struct {
+		s, want IntervalSet
+	}{
+		{IntervalSet{}, set(New(0, 10))},
+		{set(New(0, 10)), IntervalSet{}},
+		{set(New(-5, 20)), IntervalSet{}},
+		{set(New(3, 4), New(7, 12)), set(New(0, 2), New(5, 6))},
+		{set(New(-3, 0), New(10, 10)), set(New(1, 9))},
+	}
+	for _, test := range tests {
+		if got := test.s.Complement(New(0, 10)); !got.Equal(test.want) {
+			t.Errorf("complement of %v = %v, want %v", test.s, got, test.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		s    IntervalSet
+		want string
+	}{
+		{IntervalSet{}, "{}"},
+		{set(New(1, 2)), "{[1, 2]}"},
+		{set(New(5, 6), New(1, 2)), "{[1, 2] [5, 6]}"},
+	}
+	for _, test := range tests {
+		if got := test.s.String(); got != test.want {
+			t.Errorf("String() = %q, want %q", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package leaderboard
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is everything worked out from a leaderboard. Members are in order
+// of their final rank.
+type Report struct {
+	Event   string         `json:"event"`
+	Days    []int          `json:"days"` // days on which anyone got a star
+	Members []MemberReport `json:"members"`
+}
+
+type MemberReport struct {
+	ID            int         `json:"id"`
+	Name          string      `json:"name"`
+	Stars         int         `json:"stars"`
+	LocalScore    int         `json:"local_score"`
+	ReportedScore int         `json:"reported_local_score"`
+	Rank          int         `json:"rank"`
+	Days          []MemberDay `json:"days"`
+}
+
+// MemberDay is how a member did on one day, and where that left them.
+// Times are seconds from the puzzle unlocking, and missing if the star
+// wasn't got.
+type MemberDay struct {
+	Day        int    `json:"day"`
+	Part1      *int64 `json:"part1_seconds,omitempty"`
+	Part2      *int64 `json:"part2_seconds,omitempty"`
+	Delta      *int64 `json:"delta_seconds,omitempty"`
+	Points     int    `json:"points"`
+	Score      int    `json:"score"` // total so far
+	Rank       int    `json:"rank"`
+	RankChange int    `json:"rank_change"` // places gained since the day before
+}
+
+// standing is a member's position after some day.
+type standing struct {
+	key      string
+	score    int
+	lastStar Star
+}
+
+// Analyse recomputes the local scores: each star is worth one point for
+// every member who didn't get it sooner. Ranks after each day are by score,
+// then by whoever got their last star first.
+func (this *Leaderboard) Analyse() Report {
+	keys := make([]string, 0, len(this.Members))
+	for key := range this.Members {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return this.Members[keys[i]].ID < this.Members[keys[j]].ID
+	})
+
+	points := this.points(keys)
+	report := Report{Event: this.Event}
+	reports := make(map[string]*MemberReport)
+	for _, key := range keys {
+		member := this.Members[key]
+		reports[key] = &MemberReport{
+			ID:            member.ID,
+			Name:          member.DisplayName(),
+			ReportedScore: member.LocalScore,
+		}
+	}
+
+	standings := make(map[string]*standing)
+	for _, key := range keys {
+		standings[key] = &standing{key: key}
+	}
+	previousRank := make(map[string]int)
+
+	for day := 1; day <= days; day++ {
+		anyStars := false
+		for _, key := range keys {
+			member := this.Members[key]
+			s := standings[key]
+			memberDay := MemberDay{Day: day}
+
+			for part := 1; part <= 2; part++ {
+				star, found := member.Star(day, part)
+				if !found {
+					continue
+				}
+				anyStars = true
+				seconds := int64(star.Time().Sub(this.Unlock(day)) / time.Second)
+				if part == 1 {
+					memberDay.Part1 = &seconds
+				} else {
+					memberDay.Part2 = &seconds
+				}
+				memberDay.Points += points[pointKey{key, day, part}]
+				reports[key].Stars++
+				if s.lastStar.before(star) {
+					s.lastStar = star
+				}
+			}
+			if memberDay.Part1 != nil && memberDay.Part2 != nil {
+				delta := *memberDay.Part2 - *memberDay.Part1
+				memberDay.Delta = &delta
+			}
+
+			s.score += memberDay.Points
+			memberDay.Score = s.score
+			reports[key].Days = append(reports[key].Days, memberDay)
+		}
+		if !anyStars {
+			for _, key := range keys {
+				reports[key].Days = reports[key].Days[:len(reports[key].Days)-1]
+			}
+			continue
+		}
+
+		report.Days = append(report.Days, day)
+		ranks := rank(standings, keys)
+		for _, key := range keys {
+			memberDay := &reports[key].Days[len(reports[key].Days)-1]
+			memberDay.Rank = ranks[key]
+			if previous, found := previousRank[key]; found {
+				memberDay.RankChange = previous - ranks[key]
+			}
+			previousRank[key] = ranks[key]
+		}
+	}
+
+	ranks := rank(standings, keys)
+	for _, key := range keys {
+		reports[key].LocalScore = standings[key].score
+		reports[key].Rank = ranks[key]
+		report.Members = append(report.Members, *reports[key])
+	}
+	sort.SliceStable(report.Members, func(i, j int) bool {
+		return report.Members[i].Rank < report.Members[j].Rank
+	})
+
+	return report
+}
+
+type pointKey struct {
+	member    string
+	day, part int
+}
+
+// points works out what each star was worth.
+func (this *Leaderboard) points(keys []string) map[pointKey]int {
+	points := make(map[pointKey]int)
+	for day := 1; day <= days; day++ {
+		for part := 1; part <= 2; part++ {
+			got := make([]string, 0)
+			for _, key := range keys {
+				if _, found := this.Members[key].Star(day, part); found {
+					got = append(got, key)
+				}
+			}
+			sort.SliceStable(got, func(i, j int) bool {
+				a, _ := this.Members[got[i]].Star(day, part)
+				b, _ := this.Members[got[j]].Star(day, part)
+				return a.before(b)
+			})
+			for i, key := range got {
+				points[pointKey{key, day, part}] = len(keys) - i
+			}
+		}
+	}
+	return points
+}
+
+// rank orders members by score, then by who got their last star first.
+// Members with the same score and time share a rank.
+func rank(standings map[string]*standing, keys []string) map[string]int {
+	order := make([]*standing, len(keys))
+	for i, key := range keys {
+		order[i] = standings[key]
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].ahead(order[j])
+	})
+
+	ranks := make(map[string]int)
+	for i, s := range order {
+		if i > 0 && !order[i-1].ahead(s) {
+			ranks[s.key] = ranks[order[i-1].key]
+		} else {
+			ranks[s.key] = i + 1
+		}
+	}
+	return ranks
+}
+
+func (this *standing) ahead(that *standing) bool {
+	if this.score != that.score {
+		return this.score > that.score
+	}
+	if this.lastStar.GetStarTS != that.lastStar.GetStarTS {
+		return this.lastStar.GetStarTS < that.lastStar.GetStarTS
+	}
+	return false
+}
+
+// Only keeps the members whose names contain name, ignoring case.
+func (this Report) Only(name string) Report {
+	members := make([]MemberReport, 0)
+	for _, member := range this.Members {
+		if strings.Contains(strings.ToLower(member.Name), strings.ToLower(name)) {
+			members = append(members, member)
+		}
+	}
+	this.Members = members
+	return this
+}
+
+// WriteText prints the standings, then each member's timeline.
+func (this Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Leaderboard %s\n\n", this.Event)
+	fmt.Fprintln(tw, "RANK\tNAME\tSTARS\tSCORE\tREPORTED")
+	for _, member := range this.Members {
+		reported := ""
+		if member.ReportedScore != member.LocalScore {
+			reported = fmt.Sprint(member.ReportedScore)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\n", member.Rank, member.Name, member.Stars, member.LocalScore, reported)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, member := range this.Members {
+		fmt.Fprintf(w, "\n%s\n", member.Name)
+		fmt.Fprintln(tw, "DAY\tPART 1\tPART 2\tDELTA\tPOINTS\tSCORE\tRANK\tCHANGE")
+		for _, day := range member.Days {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%d\t%d\t%s\n", day.Day, formatSeconds(day.Part1), formatSeconds(day.Part2), formatSeconds(day.Delta), day.Points, day.Score, day.Rank, formatChange(day.RankChange))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatSeconds shows a time as hours:minutes:seconds, or - if missing.
+func formatSeconds(seconds *int64) string {
+	if seconds == nil {
+		return "-"
+	}
+	s := *seconds
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+func formatChange(change int) string {
+	switch {
+	case change > 0:
+		return fmt.Sprintf("+%d", change)
+	case change < 0:
+		return fmt.Sprint(change)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,104 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Leaderboard is a private leaderboard, as exported by the site.
+type Leaderboard struct {
+	Event   string            `json:"event"`
+	OwnerID int               `json:"owner_id"`
+	Members map[string]Member `json:"members"`
+}
+
+type Member struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"` // null for anonymous users
+	Stars       int    `json:"stars"`
+	LocalScore  int    `json:"local_score"`
+	GlobalScore int    `json:"global_score"`
+	LastStarTS  int64  `json:"last_star_ts"`
+
+	// Day, then part, both as strings.
+	CompletionDayLevel map[string]map[string]Star `json:"completion_day_level"`
+}
+
+type Star struct {
+	GetStarTS int64 `json:"get_star_ts"`
+	StarIndex int64 `json:"star_index"`
+}
+
+const days = 25
+
+// Puzzles unlock at midnight US Eastern time, which is UTC-5 in December.
+var unlockZone = time.FixedZone("EST", -5*60*60)
+
+func Read(r io.Reader) (*Leaderboard, error) {
+	var this Leaderboard
+	if err := json.NewDecoder(r).Decode(&this); err != nil {
+		return nil, err
+	}
+	if _, err := this.Year(); err != nil {
+		return nil, err
+	}
+	for key, member := range this.Members {
+		for day, parts := range member.CompletionDayLevel {
+			d, err := strconv.Atoi(day)
+			if err != nil || d < 1 || d > days {
+				return nil, fmt.Errorf("member %s: bad day %q", key, day)
+			}
+			for part := range parts {
+				if part != "1" && part != "2" {
+					return nil, fmt.Errorf("member %s: day %s: bad part %q", key, day, part)
+				}
+			}
+		}
+	}
+	return &this, nil
+}
+
+func (this *Leaderboard) Year() (int, error) {
+	year, err := strconv.Atoi(this.Event)
+	if err != nil {
+		return 0, fmt.Errorf("bad event %q", this.Event)
+	}
+	return year, nil
+}
+
+// Unlock is when the puzzle for the given day became available.
+func (this *Leaderboard) Unlock(day int) time.Time {
+	year, _ := this.Year()
+	return time.Date(year, time.December, day, 0, 0, 0, 0, unlockZone)
+}
+
+// DisplayName is the member's name, or what the site shows for anonymous
+// users.
+func (this Member) DisplayName() string {
+	if this.Name == "" {
+		return fmt.Sprintf("(anonymous user #%d)", this.ID)
+	}
+	return this.Name
+}
+
+// Star returns when the member got the star for a part of a day.
+func (this Member) Star(day, part int) (Star, bool) {
+	star, found := this.CompletionDayLevel[strconv.Itoa(day)][strconv.Itoa(part)]
+	return star, found
+}
+
+func (this Star) Time() time.Time {
+	return time.Unix(this.GetStarTS, 0)
+}
+
+// before orders stars by when they were got, using the order the site
+// recorded them in for stars in the same second.
+func (this Star) before(that Star) bool {
+	if this.GetStarTS != that.GetStarTS {
+		return this.GetStarTS < that.GetStarTS
+	}
+	return this.StarIndex < that.StarIndex
+}
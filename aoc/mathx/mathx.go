@@ -0,0 +1,186 @@
+package mathx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+var ErrOverflow = errors.New("integer overflow")
+var ErrNoInverse = errors.New("no modular inverse")
+var ErrNoSolution = errors.New("no solution")
+
+func Abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func Min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func Gcd(a, b int) int {
+	a, b = Abs(a), Abs(b)
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Lcm panics if the result does not fit in an int.
+func Lcm(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return MustMul(Abs(a)/Gcd(a, b), Abs(b))
+}
+
+func LcmAll(values ...int) int {
+	result := 1
+	for _, value := range values {
+		result = Lcm(result, value)
+	}
+	return result
+}
+
+// Mod returns a mod m in the range [0, m), whatever the sign of a.
+func Mod(a, m int) int {
+	r := a % m
+	if r < 0 {
+		r += m
+	}
+	return r
+}
+
+// MulMod computes a * b mod m without overflowing the intermediate product.
+func MulMod(a, b, m int) int {
+	a, b = Mod(a, m), Mod(b, m)
+	hi, lo := bits.Mul64(uint64(a), uint64(b))
+	return int(bits.Rem64(hi, lo, uint64(m)))
+}
+
+func PowMod(base, exp, m int) int {
+	if exp < 0 {
+		panic(fmt.Sprintf("PowMod: negative exponent %d", exp))
+	}
+	result := Mod(1, m)
+	base = Mod(base, m)
+	for exp > 0 {
+		if exp&1 == 1 {
+			result = MulMod(result, base, m)
+		}
+		base = MulMod(base, base, m)
+		exp >>= 1
+	}
+	return result
+}
+
+// InvMod returns x such that a * x = 1 (mod m).
+func InvMod(a, m int) (int, error) {
+	g, x, _ := extendedGcd(Mod(a, m), m)
+	if g != 1 {
+		return 0, fmt.Errorf("%w: %d mod %d", ErrNoInverse, a, m)
+	}
+	return Mod(x, m), nil
+}
+
+// CRT finds the x satisfying x = remainders[i] (mod moduli[i]) for every i,
+// returning x and the combined modulus. The moduli need not be coprime, but
+// the congruences must then agree.
+func CRT(remainders, moduli []int) (int, int, error) {
+	if len(remainders) != len(moduli) {
+		return 0, 0, fmt.Errorf("CRT: %d remainders for %d moduli", len(remainders), len(moduli))
+	}
+
+	x, m := 0, 1
+	for i := range moduli {
+		r, n := Mod(remainders[i], moduli[i]), moduli[i]
+
+		// Solve x + m * k = r (mod n) for k
+		g, p, _ := extendedGcd(m, n)
+		if (r-x)%g != 0 {
+			return 0, 0, fmt.Errorf("%w: x = %d (mod %d) and x = %d (mod %d)", ErrNoSolution, x, m, r, n)
+		}
+		step := n / g
+		k := MulMod((r-x)/g, p, step)
+
+		lcm, err := Mul(m, step)
+		if err != nil {
+			return 0, 0, err
+		}
+		x = Mod(x+MulMod(m, k, lcm), lcm)
+		m = lcm
+	}
+	return x, m, nil
+}
+
+func extendedGcd(a, b int) (int, int, int) {
+	x0, x1 := 1, 0
+	y0, y1 := 0, 1
+	for b != 0 {
+		q := a / b
+		a, b = b, a-q*b
+		x0, x1 = x1, x0-q*x1
+		y0, y1 = y1, y0-q*y1
+	}
+	return a, x0, y0
+}
+
+func Add(a, b int) (int, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, fmt.Errorf("%w: %d + %d", ErrOverflow, a, b)
+	}
+	return sum, nil
+}
+
+func Sub(a, b int) (int, error) {
+	diff := a - b
+	if (b > 0 && diff > a) || (b < 0 && diff < a) {
+		return 0, fmt.Errorf("%w: %d - %d", ErrOverflow, a, b)
+	}
+	return diff, nil
+}
+
+func Mul(a, b int) (int, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt) || (b == -1 && a == math.MinInt) {
+		return 0, fmt.Errorf("%w: %d * %d", ErrOverflow, a, b)
+	}
+	return product, nil
+}
+
+func MustAdd(a, b int) int {
+	return must(Add(a, b))
+}
+
+func MustSub(a, b int) int {
+	return must(Sub(a, b))
+}
+
+func MustMul(a, b int) int {
+	return must(Mul(a, b))
+}
+
+func must(value int, err error) int {
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
@@ -0,0 +1,202 @@
+package mathx
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMinMaxAbs(t *testing.T) {
+	tests := []struct {
+		a, b, min, max int
+	}{
+		{1, 2, 1, 2},
+		{2, 1, 1, 2},
+		{-3, 3, -3, 3},
+		{5, 5, 5, 5},
+	}
+	for _, test := range tests {
+		if got := Min(test.a, test.b); got != test.min {
+			t.Errorf("Min(%d, %d) = %d, want %d", test.a, test.b, got, test.min)
+		}
+		if got := Max(test.a, test.b); got != test.max {
+			t.Errorf("Max(%d, %d) = %d, want %d", test.a, test.b, got, test.max)
+		}
+	}
+	if Abs(-7) != 7 || Abs(7) != 7 || Abs(0) != 0 {
+		t.Error("Abs is wrong")
+	}
+}
+
+func TestGcdLcm(t *testing.T) {
+	tests := []struct {
+		a, b, gcd, lcm int
+	}{
+		{12, 18, 6, 36},
+		{-12, 18, 6, 36},
+		{7, 13, 1, 91},
+		{0, 5, 5, 0},
+	}
+	for _, test := range tests {
+		if got := Gcd(test.a, test.b); got != test.gcd {
+			t.Errorf("Gcd(%d, %d) = %d, want %d", test.a, test.b, got, test.gcd)
+		}
+		if got := Lcm(test.a, test.b); got != test.lcm {
+			t.Errorf("Lcm(%d, %d) = %d, want %d", test.a, test.b, got, test.lcm)
+		}
+	}
+	if got := LcmAll(23, 19, 13, 17); got != 96577 {
+		t.Errorf("LcmAll = %d, want 96577", got)
+	}
+}
+
+func TestMod(t *testing.T) {
+	tests := []struct {
+		a, m, want int
+	}{
+		{7, 3, 1},
+		{-7, 3, 2},
+		{-3, 3, 0},
+		{0, 5, 0},
+	}
+	for _, test := range tests {
+		if got := Mod(test.a, test.m); got != test.want {
+			t.Errorf("Mod(%d, %d) = %d, want %d", test.a, test.m, got, test.want)
+		}
+	}
+
+	// The product of these overflows an int64.
+	if got := MulMod(math.MaxInt64-1, math.MaxInt64-1, math.MaxInt64); got != 1 {
+		t.Errorf("MulMod near MaxInt64 = %d, want 1", got)
+	}
+}
+
+func TestPowMod(t *testing.T) {
+	tests := []struct {
+		base, exp, m, want int
+	}{
+		{2, 10, 1000, 24},
+		{3, 0, 7, 1},
+		{3, 0, 1, 0},
+		{-2, 3, 7, 6},
+		{2, 62, math.MaxInt64, 1 << 62},
+		{5, 1 << 40, 1000000007, 410531816},
+	}
+	for _, test := range tests {
+		if got := PowMod(test.base, test.exp, test.m); got != test.want {
+			t.Errorf("PowMod(%d, %d, %d) = %d, want %d", test.base, test.exp, test.m, got, test.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a negative exponent")
+		}
+	}()
+	PowMod(2, -1, 7)
+}
+
+func TestInvMod(t *testing.T) {
+	tests := []struct {
+		a, m, want int
+		err        error
+	}{
+		{3, 7, 5, nil},
+		{-3, 7, 2, nil},
+		{10, 17, 12, nil},
+		{4, 8, 0, ErrNoInverse},
+		{6, 9, 0, ErrNoInverse},
+		{0, 5, 0, ErrNoInverse},
+	}
+	for _, test := range tests {
+		got, err := InvMod(test.a, test.m)
+		if !errors.Is(err, test.err) || got != test.want {
+			t.Errorf("InvMod(%d, %d) = %d, %v; want %d, %v", test.a, test.m, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestCRT(t *testing.T) {
+	tests := []struct {
+		name       string
+		remainders []int
+		moduli     []int
+		x, m       int
+		err        error
+	}{
+		{"none", nil, nil, 0, 1, nil},
+		{"coprime", []int{2, 3, 2}, []int{3, 5, 7}, 23, 105, nil},
+		{"negative remainder", []int{-1, -1}, []int{4, 9}, 35, 36, nil},
+		{"not coprime", []int{2, 4}, []int{6, 8}, 20, 24, nil},
+		{"not coprime repeated", []int{1, 1, 1}, []int{4, 6, 10}, 1, 60, nil},
+		{"not coprime disagreeing", []int{1, 2}, []int{4, 6}, 0, 0, ErrNoSolution},
+		{"too big", []int{0, 0, 0}, []int{1<<31 - 1, 1<<31 + 1, 1<<31 + 3}, 0, 0, ErrOverflow},
+	}
+	for _, test := range tests {
+		x, m, err := CRT(test.remainders, test.moduli)
+		if !errors.Is(err, test.err) || x != test.x || m != test.m {
+			t.Errorf("%s: CRT = %d, %d, %v; want %d, %d, %v", test.name, x, m, err, test.x, test.m, test.err)
+		}
+	}
+
+	if _, _, err := CRT([]int{1}, []int{2, 3}); err == nil {
+		t.Error("no error for mismatched lengths")
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func(int, int) (int, error)
+		a, b int
+		want int
+		err  error
+	}{
+		{"add", Add, 1, 2, 3, nil},
+		{"add", Add, math.MaxInt, 1, 0, ErrOverflow},
+		{"add", Add, math.MinInt, -1, 0, ErrOverflow},
+		{"sub", Sub, math.MinInt + 1, 1, math.MinInt, nil},
+		{"sub", Sub, math.MinInt, 1, 0, ErrOverflow},
+		{"sub", Sub, math.MaxInt, -1, 0, ErrOverflow},
+		{"mul", Mul, -4, 5, -20, nil},
+		{"mul", Mul, 0, math.MaxInt, 0, nil},
+		{"mul", Mul, 1 << 32, 1 << 31, 0, ErrOverflow},
+		{"mul", Mul, math.MaxInt/2 + 1, 2, 0, ErrOverflow},
+		{"mul", Mul, math.MinInt, -1, 0, ErrOverflow},
+		{"mul", Mul, -1, math.MinInt, 0, ErrOverflow},
+	}
+	for _, test := range tests {
+		got, err := test.f(test.a, test.b)
+		if !errors.Is(err, test.err) || got != test.want {
+			t.Errorf("%s(%d, %d) = %d, %v; want %d, %v", test.name, test.a, test.b, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestMustPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func()
+	}{
+		{"MustAdd", func() { MustAdd(math.MaxInt, 1) }},
+		{"MustSub", func() { MustSub(math.MinInt, 1) }},
+		{"MustMul", func() { MustMul(1<<40, 1<<40) }},
+		{"MustMul", func() { MustMul(math.MinInt, -1) }},
+		{"Lcm", func() { Lcm(1<<40+1, 1<<40-1) }},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrOverflow) {
+					t.Errorf("%s: recovered %v, want an overflow", test.name, err)
+				}
+			}()
+			test.f()
+		}()
+	}
+
+	if got := MustMul(1<<31, 1<<31); got != 1<<62 {
+		t.Errorf("MustMul(1<<31, 1<<31) = %d", got)
+	}
+}
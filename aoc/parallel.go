@@ -0,0 +1,112 @@
+package aoc
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// The parallel helpers work over the indexes 0 to n-1, handing them out to
+// up to workers goroutines in chunks. Workers <= 0 means one per CPU. The
+// results never depend on how the work was shared out.
+
+// ParallelMap returns fn(i) for each index, in order.
+func ParallelMap[U any](n, workers int, fn func(i int) U) []U {
+	results := make([]U, n)
+	parallelChunks(n, workers, func(start, end int) bool {
+		for i := start; i < end; i++ {
+			results[i] = fn(i)
+		}
+		return true
+	})
+	return results
+}
+
+// ParallelReduce combines fn(i) for each index into initial, in index order,
+// so combine needn't be commutative.
+func ParallelReduce[U, A any](n, workers int, fn func(i int) U, initial A, combine func(A, U) A) A {
+	result := initial
+	for _, value := range ParallelMap(n, workers, fn) {
+		result = combine(result, value)
+	}
+	return result
+}
+
+// ParallelFind returns the result for the lowest index at which fn finds
+// something. Once something is found, no work is started on higher indexes.
+func ParallelFind[U any](n, workers int, fn func(i int) (U, bool)) (U, int, bool) {
+	var lock sync.Mutex
+	var best U
+	bestIndex := int64(n)
+
+	parallelChunks(n, workers, func(start, end int) bool {
+		for i := start; i < end; i++ {
+			if int64(i) >= atomic.LoadInt64(&bestIndex) {
+				return false
+			}
+			if value, found := fn(i); found {
+				lock.Lock()
+				if int64(i) < bestIndex {
+					best = value
+					atomic.StoreInt64(&bestIndex, int64(i))
+				}
+				lock.Unlock()
+				return false
+			}
+		}
+		return true
+	})
+
+	if bestIndex == int64(n) {
+		var nothing U
+		return nothing, -1, false
+	}
+	return best, int(bestIndex), true
+}
+
+// parallelChunks calls work on consecutive ranges of indexes, lowest first,
+// until they run out or work returns false. Other workers finish the chunks
+// they have already started.
+func parallelChunks(n, workers int, work func(start, end int) bool) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		work(0, n)
+		return
+	}
+
+	// Small enough chunks to share out evenly, large enough that handing
+	// them out is cheap.
+	chunk := n / (workers * 16)
+	if chunk < 1 {
+		chunk = 1
+	}
+
+	var next int64
+	var stopped int32
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&stopped) == 0 {
+				start := int(atomic.AddInt64(&next, int64(chunk))) - chunk
+				if start >= n {
+					return
+				}
+				end := start + chunk
+				if end > n {
+					end = n
+				}
+				if !work(start, end) {
+					atomic.StoreInt32(&stopped, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,293 @@
+package parse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Scanner tracks a position within the input being parsed.
+type Scanner struct {
+	input string
+	pos   int
+}
+
+// Error reports what was expected at the offset where parsing failed.
+type Error struct {
+	Offset   int
+	Expected string
+	Found    string
+}
+
+// Parser consumes input from the scanner. On failure it leaves the scanner
+// where the error occurred.
+type Parser[T any] func(*Scanner) (T, error)
+
+func NewScanner(input string) *Scanner {
+	return &Scanner{input, 0}
+}
+
+func (this *Scanner) Pos() int {
+	return this.pos
+}
+
+func (this *Scanner) Rest() string {
+	return this.input[this.pos:]
+}
+
+func (this *Scanner) AtEnd() bool {
+	return this.pos >= len(this.input)
+}
+
+func (this *Scanner) Peek() (byte, bool) {
+	if this.AtEnd() {
+		return 0, false
+	}
+	return this.input[this.pos], true
+}
+
+// Fail builds an error at the current position.
+func (this *Scanner) Fail(expected string) error {
+	found := "end of input"
+	if c, ok := this.Peek(); ok {
+		found = fmt.Sprintf("%q", c)
+	}
+	return &Error{this.pos, expected, found}
+}
+
+func (this *Error) Error() string {
+	return fmt.Sprintf("offset %d: expected %s, found %s", this.Offset, this.Expected, this.Found)
+}
+
+// Parse runs p over the whole of the input.
+func Parse[T any](p Parser[T], input string) (T, error) {
+	s := NewScanner(input)
+	value, err := p(s)
+	if err != nil {
+		return value, err
+	}
+	if !s.AtEnd() {
+		var nothing T
+		return nothing, s.Fail("end of input")
+	}
+	return value, nil
+}
+
+// Int parses an optionally negative decimal integer.
+func Int() Parser[int] {
+	return number(true)
+}
+
+// Uint parses a decimal integer with no sign, for counts and distances
+// which can't be negative.
+func Uint() Parser[int] {
+	return number(false)
+}
+
+func number(signed bool) Parser[int] {
+	return func(s *Scanner) (int, error) {
+		start := s.pos
+		if c, ok := s.Peek(); ok && c == '-' && signed {
+			s.pos++
+		}
+		digits := s.pos
+		for c, ok := s.Peek(); ok && c >= '0' && c <= '9'; c, ok = s.Peek() {
+			s.pos++
+		}
+		if s.pos == digits {
+			return 0, s.Fail("digit")
+		}
+		value, err := strconv.Atoi(s.input[start:s.pos])
+		if err != nil {
+			s.pos = start
+			return 0, &Error{start, "integer", err.Error()}
+		}
+		return value, nil
+	}
+}
+
+// Literal matches exactly the given text.
+func Literal(text string) Parser[string] {
+	return func(s *Scanner) (string, error) {
+		if !strings.HasPrefix(s.Rest(), text) {
+			return "", s.Fail(fmt.Sprintf("%q", text))
+		}
+		s.pos += len(text)
+		return text, nil
+	}
+}
+
+// While matches one or more bytes satisfying accept.
+func While(description string, accept func(byte) bool) Parser[string] {
+	return func(s *Scanner) (string, error) {
+		start := s.pos
+		for c, ok := s.Peek(); ok && accept(c); c, ok = s.Peek() {
+			s.pos++
+		}
+		if s.pos == start {
+			return "", s.Fail(description)
+		}
+		return s.input[start:s.pos], nil
+	}
+}
+
+func Letters() Parser[string] {
+	return While("letter", func(c byte) bool {
+		return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+	})
+}
+
+func Map[T, U any](p Parser[T], fn func(T) U) Parser[U] {
+	return func(s *Scanner) (U, error) {
+		value, err := p(s)
+		if err != nil {
+			var nothing U
+			return nothing, err
+		}
+		return fn(value), nil
+	}
+}
+
+// Choice returns the result of the first parser to succeed. If they all
+// fail, the error is the one which got furthest into the input, listing
+// everything that would have been accepted there.
+func Choice[T any](parsers ...Parser[T]) Parser[T] {
+	return func(s *Scanner) (T, error) {
+		start := s.pos
+		best := s.Fail("something")
+		bestPos := start
+		for i, p := range parsers {
+			value, err := p(s)
+			if err == nil {
+				return value, nil
+			}
+			if s.pos > bestPos || i == 0 {
+				best, bestPos = err, s.pos
+			} else if s.pos == bestPos {
+				best = mergeErrors(best, err)
+			}
+			s.pos = start
+		}
+		var nothing T
+		s.pos = bestPos
+		return nothing, best
+	}
+}
+
+func mergeErrors(a, b error) error {
+	aErr, aOk := a.(*Error)
+	bErr, bOk := b.(*Error)
+	if !aOk || !bOk || aErr.Offset != bErr.Offset {
+		return a
+	}
+	return &Error{aErr.Offset, aErr.Expected + " or " + bErr.Expected, aErr.Found}
+}
+
+// Many matches p zero or more times. A failure which consumed input is
+// reported rather than treated as the end of the list, and a match which
+// consumed nothing ends the list, as it would otherwise repeat forever.
+func Many[T any](p Parser[T]) Parser[[]T] {
+	return func(s *Scanner) ([]T, error) {
+		values := make([]T, 0)
+		for !s.AtEnd() {
+			start := s.pos
+			value, err := p(s)
+			if err != nil {
+				if s.pos != start {
+					return nil, err
+				}
+				break
+			}
+			if s.pos == start {
+				break
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+}
+
+// SepBy matches zero or more p separated by sep.
+func SepBy[T, U any](p Parser[T], sep Parser[U]) Parser[[]T] {
+	return func(s *Scanner) ([]T, error) {
+		values := make([]T, 0)
+		start := s.pos
+		value, err := p(s)
+		if err != nil {
+			if s.pos != start {
+				return nil, err
+			}
+			s.pos = start
+			return values, nil
+		}
+		values = append(values, value)
+
+		for {
+			start = s.pos
+			if _, err := sep(s); err != nil {
+				s.pos = start
+				return values, nil
+			}
+			value, err := p(s)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	}
+}
+
+// ListOf matches items separated by sep, between open and close.
+func ListOf[T, U any](open string, item Parser[T], sep Parser[U], close string) Parser[[]T] {
+	return Between(Literal(open), SepBy(item, sep), Literal(close))
+}
+
+func Between[T, U, V any](open Parser[U], p Parser[T], close Parser[V]) Parser[T] {
+	return func(s *Scanner) (T, error) {
+		var nothing T
+		if _, err := open(s); err != nil {
+			return nothing, err
+		}
+		value, err := p(s)
+		if err != nil {
+			return nothing, err
+		}
+		if _, err := close(s); err != nil {
+			return nothing, err
+		}
+		return value, nil
+	}
+}
+
+// Skip matches p followed by after, keeping only the value of p.
+func Skip[T, U any](p Parser[T], after Parser[U]) Parser[T] {
+	return func(s *Scanner) (T, error) {
+		value, err := p(s)
+		if err != nil {
+			return value, err
+		}
+		if _, err := after(s); err != nil {
+			var nothing T
+			return nothing, err
+		}
+		return value, nil
+	}
+}
+
+// Preceded matches before followed by p, keeping only the value of p.
+func Preceded[T, U any](before Parser[U], p Parser[T]) Parser[T] {
+	return func(s *Scanner) (T, error) {
+		if _, err := before(s); err != nil {
+			var nothing T
+			return nothing, err
+		}
+		return p(s)
+	}
+}
+
+// Lazy defers building a parser until it is used, for recursive grammars.
+func Lazy[T any](build func() Parser[T]) Parser[T] {
+	return func(s *Scanner) (T, error) {
+		return build()(s)
+	}
+}
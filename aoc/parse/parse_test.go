@@ -0,0 +1,193 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// checkError compares err with the error expected, written as
+// "offset / expected / found", or "" for none.
+func checkError(t *testing.T, name, input string, err error, want string) {
+	t.Helper()
+	if want == "" {
+		if err != nil {
+			t.Errorf("%s(%q): %v", name, input, err)
+		}
+		return
+	}
+
+	var parseErr *Error
+	if !errors.As(err, &parseErr) {
+		t.Errorf("%s(%q): error %v, want %s", name, input, err, want)
+		return
+	}
+	if got := fmt.Sprintf("%d / %s / %s", parseErr.Offset, parseErr.Expected, parseErr.Found); got != want {
+		t.Errorf("%s(%q): error %s, want %s", name, input, got, want)
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		name  string
+		p     Parser[int]
+		input string
+		value int
+		err   string
+	}{
+		{"Int", Int(), "42", 42, ""},
+		{"Int", Int(), "-42", -42, ""},
+		{"Int", Int(), "007", 7, ""},
+		{"Int", Int(), "", 0, "0 / digit / end of input"},
+		{"Int", Int(), "x", 0, "0 / digit / 'x'"},
+		{"Int", Int(), "-", 0, "1 / digit / end of input"},
+		{"Int", Int(), "--1", 0, "1 / digit / '-'"},
+		{"Int", Int(), "12x", 0, "2 / end of input / 'x'"},
+		{"Int", Int(), "99999999999999999999", 0, `0 / integer / strconv.Atoi: parsing "99999999999999999999": value out of range`},
+		{"Uint", Uint(), "42", 42, ""},
+		{"Uint", Uint(), "0", 0, ""},
+		{"Uint", Uint(), "-42", 0, "0 / digit / '-'"},
+		{"Uint", Uint(), "+42", 0, "0 / digit / '+'"},
+	}
+	for _, test := range tests {
+		value, err := Parse(test.p, test.input)
+		checkError(t, test.name, test.input, err, test.err)
+		if err == nil && value != test.value {
+			t.Errorf("%s(%q) = %d, want %d", test.name, test.input, value, test.value)
+		}
+	}
+}
+
+// The instructions of day 22, which take only unsigned distances.
+type instruction struct {
+	distance int
+	turn     string
+}
+
+var instructions = Many(Choice(
+	Map(Uint(), func(distance int) instruction { return instruction{distance: distance} }),
+	Map(Literal("L"), func(turn string) instruction { return instruction{turn: turn} }),
+	Map(Literal("R"), func(turn string) instruction { return instruction{turn: turn} }),
+))
+
+func TestChoice(t *testing.T) {
+	ab := Preceded(Literal("a"), Literal("b"))
+	tests := []struct {
+		name  string
+		p     Parser[string]
+		input string
+		value string
+		err   string
+	}{
+		{"first", Choice(Literal("a"), Literal("b")), "a", "a", ""},
+		{"second", Choice(Literal("a"), Literal("b")), "b", "b", ""},
+		{"first of several matching", Choice(Literal("a"), Literal("ab"), Literal("abc")), "a", "a", ""},
+		{"errors merged", Choice(Literal("a"), Literal("b")), "c", "", `0 / "a" or "b" / 'c'`},
+		{"all merged", Choice(Literal("a"), Literal("b"), Letters()), "1", "", `0 / "a" or "b" or letter / '1'`},
+		{"at the end", Choice(Literal("a"), Literal("b")), "", "", `0 / "a" or "b" / end of input`},
+		{"furthest first", Choice(ab, Literal("c")), "ax", "", `1 / "b" / 'x'`},
+		{"furthest second", Choice(Literal("c"), ab), "ax", "", `1 / "b" / 'x'`},
+		{"furthest among equals", Choice(Literal("c"), ab, Preceded(Literal("a"), Literal("d"))), "ax", "", `1 / "b" or "d" / 'x'`},
+		{"nested", Choice(Choice(Literal("a"), Literal("b")), Literal("c")), "d", "", `0 / "a" or "b" or "c" / 'd'`},
+	}
+	for _, test := range tests {
+		value, err := Parse(test.p, test.input)
+		checkError(t, test.name, test.input, err, test.err)
+		if err == nil && value != test.value {
+			t.Errorf("%s(%q) = %q, want %q", test.name, test.input, value, test.value)
+		}
+	}
+}
+
+func TestMany(t *testing.T) {
+	tests := []struct {
+		name  string
+		p     Parser[[]string]
+		input string
+		value []string
+		err   string
+	}{
+		{"none", Many(Literal("ab")), "", []string{}, ""},
+		{"several", Many(Literal("ab")), "ababab", []string{"ab", "ab", "ab"}, ""},
+		{"stops without progress", Many(Literal("ab")), "ababx", nil, "4 / end of input / 'x'"},
+		{"stops on a partial match", Many(Literal("ab")), "aba", nil, "2 / end of input / 'a'"},
+		{"reports failure after progress", Many(Preceded(Literal("a"), Literal("b"))), "abac", nil, `3 / "b" / 'c'`},
+		{"stops on an empty match", Many(Map(SepBy(Letters(), Literal(",")), func(words []string) string {
+			return strings.Join(words, "+")
+		})), "a,b", []string{"a+b"}, ""},
+		{"stops on an empty match before more", Many(Map(SepBy(Letters(), Literal(",")), func(words []string) string {
+			return strings.Join(words, "+")
+		})), "a,b;", nil, "3 / end of input / ';'"},
+	}
+	for _, test := range tests {
+		value, err := Parse(test.p, test.input)
+		checkError(t, test.name, test.input, err, test.err)
+		if err == nil && !reflect.DeepEqual(value, test.value) {
+			t.Errorf("%s(%q) = %q, want %q", test.name, test.input, value, test.value)
+		}
+	}
+}
+
+func TestInstructions(t *testing.T) {
+	tests := []struct {
+		input string
+		value []instruction
+		err   string
+	}{
+		{"10R5L5", []instruction{{10, ""}, {0, "R"}, {5, ""}, {0, "L"}, {5, ""}}, ""},
+		{"", []instruction{}, ""},
+		{"10R-5L", nil, "3 / end of input / '-'"},
+		{"10RX", nil, "3 / end of input / 'X'"},
+	}
+	for _, test := range tests {
+		value, err := Parse(instructions, test.input)
+		checkError(t, "instructions", test.input, err, test.err)
+		if err == nil && !reflect.DeepEqual(value, test.value) {
+			t.Errorf("instructions(%q) = %v, want %v", test.input, value, test.value)
+		}
+	}
+}
+
+// A nested list like the packets of day 13.
+type list struct {
+	value int
+	items []list
+}
+
+func listParser() Parser[list] {
+	return Choice(
+		Map(Int(), func(value int) list { return list{value: value} }),
+		Map(ListOf("[", Lazy(listParser), Literal(","), "]"), func(items []list) list {
+			return list{items: items}
+		}),
+	)
+}
+
+func TestListOf(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"[]", ""},
+		{"[1,[2,[3]],-4]", ""},
+		{"[1,2", `4 / "]" / end of input`},
+		{"[1,,2]", `3 / digit or "[" / ','`},
+		{"[1 2]", `2 / "]" / ' '`},
+		{"[1,[2,x]]", `6 / digit or "[" / 'x'`},
+		{"[1]]", `3 / end of input / ']'`},
+		{"", `0 / digit or "[" / end of input`},
+	}
+	for _, test := range tests {
+		_, err := Parse(listParser(), test.input)
+		checkError(t, "list", test.input, err, test.err)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	_, err := Parse(Literal("abc"), "abd")
+	if got, want := err.Error(), `offset 0: expected "abc", found 'a'`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,220 @@
+package search
+
+import (
+	"sort"
+	"sync"
+)
+
+// Problem describes a search which advances a frontier of states one step at
+// a time. States sharing a key are interchangeable apart from how good they
+// are, so only the best of them is kept.
+type Problem[S any, K comparable] struct {
+	// Key identifies states which lead to the same futures.
+	Key func(S) K
+
+	// Next calls emit with each successor of state. The step counts from
+	// zero at the start of the search.
+	Next func(state S, step int, emit func(S))
+
+	// Score is the final score a state is guaranteed to reach even if
+	// nothing more is done with it. The search maximises it.
+	Score func(S) int
+
+	// Dominates reports whether a makes b redundant when they share a key.
+	// Defaults to comparing scores.
+	Dominates func(a, b S) bool
+
+	// Bound is an optimistic upper bound on the final score of any
+	// descendant of a state. States which can't beat the best score found so
+	// far are pruned. Optional.
+	Bound func(S) int
+
+	// Beam keeps only the best Beam states after each step. Zero means
+	// there is no limit.
+	Beam int
+
+	// Memo remembers the best state for each key across the whole search,
+	// rather than just within each step.
+	Memo bool
+
+	// Workers expands the frontier in that many goroutines. The results are
+	// merged in frontier order, so the outcome doesn't depend on it.
+	Workers int
+
+	// OnStep is called after each step with everything needed to resume
+	// the search from there. Optional.
+	OnStep func(Progress[S])
+}
+
+type Result[S any] struct {
+	Best     S
+	Score    int
+	Found    bool
+	Explored int // successor states generated
+	Kept     int // successor states added to a frontier
+}
+
+// Progress is a search part way through. The memo isn't included, so a
+// resumed search may revisit some states, but reaches the same result.
+type Progress[S any] struct {
+	Step     int
+	Frontier []S
+	Result   Result[S]
+}
+
+type searcher[S any, K comparable] struct {
+	Problem[S, K]
+	result   Result[S]
+	memo     map[K]S
+	frontier []S
+	index    map[K]int
+}
+
+// Run searches from the start states for the given number of steps.
+func (this Problem[S, K]) Run(start []S, steps int) Result[S] {
+	return this.Resume(Progress[S]{Frontier: start}, steps)
+}
+
+// Resume continues a search from where OnStep left it.
+func (this Problem[S, K]) Resume(progress Progress[S], steps int) Result[S] {
+	s := &searcher[S, K]{Problem: this, result: progress.Result}
+	if s.Dominates == nil {
+		s.Dominates = func(a, b S) bool { return s.Score(a) >= s.Score(b) }
+	}
+	if s.Memo {
+		s.memo = make(map[K]S)
+	}
+
+	kept := s.result.Kept
+	s.reset()
+	for _, state := range progress.Frontier {
+		s.add(state)
+	}
+	s.result.Kept = kept
+
+	for step := progress.Step; step < steps; step++ {
+		current := s.frontier
+		s.reset()
+
+		for _, successors := range s.expand(current, step) {
+			s.result.Explored += len(successors)
+			for _, state := range successors {
+				s.add(state)
+			}
+		}
+		s.applyBeam()
+
+		if s.OnStep != nil {
+			s.OnStep(Progress[S]{step + 1, s.frontier, s.result})
+		}
+	}
+
+	return s.result
+}
+
+// ConvertProgress changes the type of the states in progress, such as to
+// and from a form which can be saved.
+func ConvertProgress[S, T any](progress Progress[S], convert func(S) T) Progress[T] {
+	frontier := make([]T, len(progress.Frontier))
+	for i, state := range progress.Frontier {
+		frontier[i] = convert(state)
+	}
+
+	var best T
+	if progress.Result.Found {
+		best = convert(progress.Result.Best)
+	}
+
+	return Progress[T]{
+		Step:     progress.Step,
+		Frontier: frontier,
+		Result: Result[T]{
+			Best:     best,
+			Score:    progress.Result.Score,
+			Found:    progress.Result.Found,
+			Explored: progress.Result.Explored,
+			Kept:     progress.Result.Kept,
+		},
+	}
+}
+
+func (this *searcher[S, K]) reset() {
+	this.frontier = nil
+	this.index = make(map[K]int)
+}
+
+func (this *searcher[S, K]) add(state S) {
+	score := this.Score(state)
+	if this.Bound != nil && this.result.Found && this.Bound(state) < this.result.Score {
+		return
+	}
+
+	key := this.Key(state)
+	if this.Memo {
+		if best, found := this.memo[key]; found && this.Dominates(best, state) {
+			return
+		}
+	}
+
+	if i, found := this.index[key]; found {
+		if this.Dominates(this.frontier[i], state) {
+			return
+		}
+		this.frontier[i] = state
+	} else {
+		this.index[key] = len(this.frontier)
+		this.frontier = append(this.frontier, state)
+	}
+	if this.Memo {
+		this.memo[key] = state
+	}
+	this.result.Kept++
+
+	if !this.result.Found || score > this.result.Score {
+		this.result.Best = state
+		this.result.Score = score
+		this.result.Found = true
+	}
+}
+
+// expand returns the successors of each chunk of the frontier, in order.
+func (this *searcher[S, K]) expand(frontier []S, step int) [][]S {
+	workers := this.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(frontier) {
+		workers = len(frontier)
+	}
+
+	chunks := make([][]S, workers)
+	var wg sync.WaitGroup
+	for w := range chunks {
+		lo := w * len(frontier) / workers
+		hi := (w + 1) * len(frontier) / workers
+
+		wg.Add(1)
+		go func(w int, states []S) {
+			defer wg.Done()
+			emit := func(next S) {
+				chunks[w] = append(chunks[w], next)
+			}
+			for _, state := range states {
+				this.Next(state, step, emit)
+			}
+		}(w, frontier[lo:hi])
+	}
+	wg.Wait()
+
+	return chunks
+}
+
+func (this *searcher[S, K]) applyBeam() {
+	if this.Beam <= 0 || len(this.frontier) <= this.Beam {
+		return
+	}
+	sort.SliceStable(this.frontier, func(i, j int) bool {
+		return this.Score(this.frontier[i]) > this.Score(this.frontier[j])
+	})
+	this.frontier = this.frontier[:this.Beam]
+}
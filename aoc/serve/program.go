@@ -0,0 +1,168 @@
+package serve
+
+import (
+	"advent-of-code/aoc"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Program solves puzzles by running one of the day binaries on a copy of the
+// input. The binaries print their answers as JSON when given -json, and take
+// any parameters as flags.
+type Program struct {
+	Path string
+}
+
+var paramName = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9-]*$")
+
+var dayBinary = regexp.MustCompile(`^day(\d\d)$`)
+
+// dayParams are the flags of each day which may be set over HTTP: only
+// those which change the answers. Anything naming a file, such as
+// -checkpoint or -rules, or switching to another mode, such as -explore, is
+// left off, as is -json which Answers relies on.
+var dayParams = map[int][]string{
+	1: {"k"},
+	3: {"group", "alphabet"},
+}
+
+// dayParts are the parts each day answers, where that isn't both of them:
+// day 22 only solves the flat map, and day 25 has no second part.
+var dayParts = map[int][]int{
+	22: {1},
+	25: {1},
+}
+
+func (this Program) Solve(ctx context.Context, input []byte, part int, params url.Values) (aoc.Answer, error) {
+	args, err := flagArgs(params)
+	if err != nil {
+		return aoc.Answer{}, err
+	}
+
+	file, err := os.CreateTemp("", "aoc-input-*.txt")
+	if err != nil {
+		return aoc.Answer{}, err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(input)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return aoc.Answer{}, err
+	}
+
+	answers, err := this.Answers(ctx, append(args, file.Name())...)
+	if err != nil {
+		return aoc.Answer{}, err
+	}
+
+	answer, found := answers[part]
+	if !found {
+		return aoc.Answer{}, fmt.Errorf("%s printed no answer for part %d", filepath.Base(this.Path), part)
+	}
+	return answer, nil
+}
+
+// Answers runs the program and collects the answers it prints, by part.
+func (this Program) Answers(ctx context.Context, args ...string) (map[int]aoc.Answer, error) {
+	output, err := this.Run(ctx, append([]string{"-json"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	answers := make(map[int]aoc.Answer)
+	decoder := json.NewDecoder(strings.NewReader(output))
+	for decoder.More() {
+		var answer aoc.PartAnswer
+		if err := decoder.Decode(&answer); err != nil {
+			return nil, fmt.Errorf("%s: bad answer: %v", filepath.Base(this.Path), err)
+		}
+		answers[answer.Part] = answer.Answer
+	}
+	return answers, nil
+}
+
+// Run runs the program, returning what it printed. If it fails, the error
+// includes what it printed to stderr.
+func (this Program) Run(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, this.Path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%s: %v: %s", filepath.Base(this.Path), err, message)
+		}
+		return "", fmt.Errorf("%s: %v", filepath.Base(this.Path), err)
+	}
+	return stdout.String(), nil
+}
+
+func flagArgs(params url.Values) ([]string, error) {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		if !paramName.MatchString(name) {
+			return nil, fmt.Errorf("bad parameter name %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := make([]string, 0)
+	for _, name := range names {
+		for _, value := range params[name] {
+			args = append(args, "-"+name+"="+value)
+		}
+	}
+	return args, nil
+}
+
+// FindPrograms returns the built dayNN/dayNN binaries under root, by day.
+func FindPrograms(root string) (map[int]Program, error) {
+	dirs, err := filepath.Glob(filepath.Join(root, "day[0-9][0-9]"))
+	if err != nil {
+		return nil, err
+	}
+
+	programs := make(map[int]Program)
+	for _, dir := range dirs {
+		name := filepath.Base(dir)
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+			continue
+		}
+		var day int
+		fmt.Sscanf(dayBinary.FindStringSubmatch(name)[1], "%d", &day)
+		programs[day] = Program{path}
+	}
+	return programs, nil
+}
+
+// Discover registers every built day binary under root, for the parts it
+// answers.
+func Discover(registry *Registry, root string, year int) error {
+	programs, err := FindPrograms(root)
+	if err != nil {
+		return err
+	}
+	for day, program := range programs {
+		parts, found := dayParts[day]
+		if !found {
+			parts = []int{1, 2}
+		}
+		registry.Register(year, day, parts, dayParams[day], program)
+	}
+	return nil
+}
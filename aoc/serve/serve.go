@@ -0,0 +1,227 @@
+package serve
+
+import (
+	"advent-of-code/aoc"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Solver produces the answer to one part of a puzzle from its input.
+type Solver interface {
+	Solve(ctx context.Context, input []byte, part int, params url.Values) (aoc.Answer, error)
+}
+
+// SolverFunc lets an ordinary function be used as a Solver.
+type SolverFunc func(ctx context.Context, input []byte, part int, params url.Values) (aoc.Answer, error)
+
+type Puzzle struct {
+	Year   int      `json:"year"`
+	Day    int      `json:"day"`
+	Parts  []int    `json:"parts"`
+	Params []string `json:"params"` // the only query parameters accepted
+
+	solver Solver
+}
+
+// Registry holds the solvers available to the server.
+type Registry struct {
+	puzzles map[[2]int]Puzzle
+}
+
+type Options struct {
+	Timeout time.Duration // per request
+	MaxBody int64         // bytes of puzzle input
+}
+
+type Server struct {
+	registry *Registry
+	options  Options
+}
+
+type Answer struct {
+	Year     int     `json:"year"`
+	Day      int     `json:"day"`
+	Part     int     `json:"part"`
+	Answer   string  `json:"answer"` // canonical form
+	Kind     string  `json:"kind"`
+	Duration string  `json:"duration"`
+	Millis   float64 `json:"millis"`
+}
+
+type Error struct {
+	Error string `json:"error"`
+}
+
+var DefaultOptions = Options{
+	Timeout: 30 * time.Second,
+	MaxBody: 1 << 20,
+}
+
+func (this SolverFunc) Solve(ctx context.Context, input []byte, part int, params url.Values) (aoc.Answer, error) {
+	return this(ctx, input, part, params)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{make(map[[2]int]Puzzle)}
+}
+
+// Register adds a solver. Requests giving any query parameter not in params
+// are refused, so solvers never see anything they haven't agreed to.
+func (this *Registry) Register(year, day int, parts []int, params []string, solver Solver) {
+	if params == nil {
+		params = []string{}
+	}
+	this.puzzles[[2]int{year, day}] = Puzzle{year, day, parts, params, solver}
+}
+
+func (this *Registry) Lookup(year, day int) (Puzzle, bool) {
+	puzzle, found := this.puzzles[[2]int{year, day}]
+	return puzzle, found
+}
+
+// Puzzles lists everything registered, in date order.
+func (this *Registry) Puzzles() []Puzzle {
+	puzzles := make([]Puzzle, 0, len(this.puzzles))
+	for _, puzzle := range this.puzzles {
+		puzzles = append(puzzles, puzzle)
+	}
+	sort.Slice(puzzles, func(i, j int) bool {
+		if puzzles[i].Year != puzzles[j].Year {
+			return puzzles[i].Year < puzzles[j].Year
+		}
+		return puzzles[i].Day < puzzles[j].Day
+	})
+	return puzzles
+}
+
+func (this Puzzle) HasPart(part int) bool {
+	for _, p := range this.Parts {
+		if p == part {
+			return true
+		}
+	}
+	return false
+}
+
+func (this Puzzle) AllowsParam(name string) bool {
+	for _, param := range this.Params {
+		if param == name {
+			return true
+		}
+	}
+	return false
+}
+
+func NewServer(registry *Registry, options Options) *Server {
+	return &Server{registry, options}
+}
+
+// ServeHTTP handles
+//
+//	GET  /v1/days
+//	POST /v1/{year}/{day}/{part}
+func (this *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(path) == 2 && path[0] == "v1" && path[1] == "days":
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "use GET")
+			return
+		}
+		writeJSON(w, http.StatusOK, this.registry.Puzzles())
+
+	case len(path) == 4 && path[0] == "v1":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "use POST")
+			return
+		}
+		this.solve(w, r, path[1:])
+
+	default:
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	}
+}
+
+func (this *Server) solve(w http.ResponseWriter, r *http.Request, path []string) {
+	numbers := make([]int, len(path))
+	for i, s := range path {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("bad number %q in path", s))
+			return
+		}
+		numbers[i] = n
+	}
+	year, day, part := numbers[0], numbers[1], numbers[2]
+
+	puzzle, found := this.registry.Lookup(year, day)
+	if !found || !puzzle.HasPart(part) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no solver for %d day %d part %d", year, day, part))
+		return
+	}
+
+	params := r.URL.Query()
+	for name := range params {
+		if !puzzle.AllowsParam(name) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("parameter %q is not accepted for %d day %d", name, year, day))
+			return
+		}
+	}
+
+	input, err := io.ReadAll(http.MaxBytesReader(w, r.Body, this.options.MaxBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("input is limited to %d bytes", tooLarge.Limit))
+		} else {
+			writeError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), this.options.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	answer, err := puzzle.solver.Solve(ctx, input, part, params)
+	elapsed := time.Since(start)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		writeError(w, http.StatusGatewayTimeout, fmt.Sprintf("no answer within %v", this.options.Timeout))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Answer{
+		Year:     year,
+		Day:      day,
+		Part:     part,
+		Answer:   answer.String(),
+		Kind:     answer.Kind().String(),
+		Duration: elapsed.String(),
+		Millis:   float64(elapsed.Microseconds()) / 1000,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, Error{message})
+}
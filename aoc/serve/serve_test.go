@@ -0,0 +1,285 @@
+package serve
+
+import (
+	"advent-of-code/aoc"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The tests run their own binary as the day program, with fakeProgram
+// saying how it should behave.
+const fakeProgram = "SERVE_TEST_PROGRAM"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(fakeProgram) {
+	case "":
+		os.Exit(m.Run())
+	case "answer":
+		// The answer to part 1 is the number of arguments, including -json
+		// and the input file.
+		json.NewEncoder(os.Stdout).Encode(aoc.PartAnswer{Part: 1, Answer: aoc.IntAnswer(len(os.Args) - 1)})
+		os.Exit(0)
+	case "sleep":
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	case "fail":
+		fmt.Fprintln(os.Stderr, "bad input")
+		os.Exit(1)
+	}
+}
+
+func newTestServer(t *testing.T, options Options) *httptest.Server {
+	registry := NewRegistry()
+	registry.Register(2022, 1, []int{1, 2}, []string{"k"}, SolverFunc(func(ctx context.Context, input []byte, part int, params url.Values) (aoc.Answer, error) {
+		return aoc.StringAnswer(fmt.Sprintf("part %d of %q k=%s", part, input, params.Get("k"))), nil
+	}))
+	registry.Register(2022, 2, []int{1}, nil, Program{os.Args[0]})
+
+	server := httptest.NewServer(NewServer(registry, options))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func request(t *testing.T, server *httptest.Server, method, path, body string) (int, map[string]any) {
+	t.Helper()
+	r, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := server.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var decoded any // a list for /v1/days
+	if err := json.NewDecoder(response.Body).Decode(&decoded); err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	object, _ := decoded.(map[string]any)
+	return response.StatusCode, object
+}
+
+func TestRouting(t *testing.T) {
+	server := newTestServer(t, DefaultOptions)
+
+	tests := []struct {
+		method, path string
+		status       int
+		errorText    string
+	}{
+		{"GET", "/v1/days", http.StatusOK, ""},
+		{"POST", "/v1/days", http.StatusMethodNotAllowed, "use GET"},
+		{"POST", "/v1/2022/1/2", http.StatusOK, ""},
+		{"GET", "/v1/2022/1/2", http.StatusMethodNotAllowed, "use POST"},
+		{"GET", "/", http.StatusNotFound, "no such endpoint"},
+		{"POST", "/v1/2022/1", http.StatusNotFound, "no such endpoint"},
+		{"POST", "/v2/2022/1/1", http.StatusNotFound, "no such endpoint"},
+		{"POST", "/v1/2022/one/1", http.StatusNotFound, `bad number "one"`},
+		{"POST", "/v1/2022/3/1", http.StatusNotFound, "no solver for 2022 day 3 part 1"},
+		{"POST", "/v1/2021/1/1", http.StatusNotFound, "no solver for 2021 day 1 part 1"},
+		{"POST", "/v1/2022/1/3", http.StatusNotFound, "no solver for 2022 day 1 part 3"},
+		{"POST", "/v1/2022/2/2", http.StatusNotFound, "no solver for 2022 day 2 part 2"},
+	}
+	for _, test := range tests {
+		status, body := request(t, server, test.method, test.path, "input")
+		if status != test.status {
+			t.Errorf("%s %s: status %d, want %d", test.method, test.path, status, test.status)
+		}
+		if test.errorText != "" && !strings.Contains(fmt.Sprint(body["error"]), test.errorText) {
+			t.Errorf("%s %s: error %q, want it to contain %q", test.method, test.path, body["error"], test.errorText)
+		}
+	}
+}
+
+func TestDays(t *testing.T) {
+	server := newTestServer(t, DefaultOptions)
+
+	response, err := server.Client().Get(server.URL + "/v1/days")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	got, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[{"year":2022,"day":1,"parts":[1,2],"params":["k"]},{"year":2022,"day":2,"parts":[1],"params":[]}]` + "\n"
+	if string(got) != want {
+		t.Errorf("days = %s, want %s", got, want)
+	}
+}
+
+func TestSolve(t *testing.T) {
+	server := newTestServer(t, DefaultOptions)
+
+	status, body := request(t, server, "POST", "/v1/2022/1/2?k=5", "some input")
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %v", status, body)
+	}
+	if want := `part 2 of "some input" k=5`; body["answer"] != want {
+		t.Errorf("answer %q, want %q", body["answer"], want)
+	}
+	if body["kind"] != "string" || body["year"] != 2022.0 || body["day"] != 1.0 || body["part"] != 2.0 {
+		t.Errorf("unexpected response %v", body)
+	}
+}
+
+func TestRejectedParams(t *testing.T) {
+	server := newTestServer(t, DefaultOptions)
+
+	tests := []string{
+		"/v1/2022/1/1?checkpoint=/tmp/x",
+		"/v1/2022/1/1?k=1&explore=true",
+		"/v1/2022/1/1?json=false",
+		"/v1/2022/2/1?k=1",
+	}
+	for _, path := range tests {
+		status, body := request(t, server, "POST", path, "input")
+		if status != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", path, status, http.StatusBadRequest)
+		}
+		if !strings.Contains(fmt.Sprint(body["error"]), "not accepted") {
+			t.Errorf("%s: error %q", path, body["error"])
+		}
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	server := newTestServer(t, Options{Timeout: time.Second, MaxBody: 4})
+
+	status, _ := request(t, server, "POST", "/v1/2022/1/1", "1234")
+	if status != http.StatusOK {
+		t.Errorf("status %d for input at the limit", status)
+	}
+	status, body := request(t, server, "POST", "/v1/2022/1/1", "12345")
+	if status != http.StatusRequestEntityTooLarge {
+		t.Errorf("status %d for input over the limit: %v", status, body)
+	}
+}
+
+func TestProgram(t *testing.T) {
+	tests := []struct {
+		behaviour string
+		status    int
+		answer    string
+		errorText string
+	}{
+		{"answer", http.StatusOK, "2", ""},
+		{"fail", http.StatusUnprocessableEntity, "", "bad input"},
+		{"sleep", http.StatusGatewayTimeout, "", "no answer within 100ms"},
+	}
+	for _, test := range tests {
+		t.Setenv(fakeProgram, test.behaviour)
+		server := newTestServer(t, Options{Timeout: 100 * time.Millisecond, MaxBody: 100})
+
+		status, body := request(t, server, "POST", "/v1/2022/2/1", "input")
+		if status != test.status {
+			t.Errorf("%s: status %d, want %d: %v", test.behaviour, status, test.status, body)
+		}
+		if test.answer != "" && body["answer"] != test.answer {
+			t.Errorf("%s: answer %q, want %q", test.behaviour, body["answer"], test.answer)
+		}
+		if test.errorText != "" && !strings.Contains(fmt.Sprint(body["error"]), test.errorText) {
+			t.Errorf("%s: error %q, want it to contain %q", test.behaviour, body["error"], test.errorText)
+		}
+	}
+}
+
+func TestFlagArgs(t *testing.T) {
+	args, err := flagArgs(url.Values{"k": {"3"}, "alphabet": {"abc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(args, " "), "-alphabet=abc -k=3"; got != want {
+		t.Errorf("args %q, want %q", got, want)
+	}
+
+	if _, err := flagArgs(url.Values{"-checkpoint": {"x"}}); err == nil {
+		t.Error("no error for a parameter name starting with -")
+	}
+}
+
+// Discover registers only the parts a day answers, so asking for a part it
+// doesn't is a missing solver rather than a failed run.
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"day01", "day22", "day25"} {
+		if err := os.Mkdir(filepath.Join(root, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(os.Args[0], filepath.Join(root, name, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry := NewRegistry()
+	if err := Discover(registry, root, 2022); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(NewServer(registry, DefaultOptions))
+	t.Cleanup(server.Close)
+	t.Setenv(fakeProgram, "answer")
+
+	tests := []struct {
+		path      string
+		status    int
+		errorText string
+	}{
+		{"/v1/2022/1/1", http.StatusOK, ""},
+		{"/v1/2022/22/1", http.StatusOK, ""},
+		{"/v1/2022/22/2", http.StatusNotFound, "no solver for 2022 day 22 part 2"},
+		{"/v1/2022/25/2", http.StatusNotFound, "no solver for 2022 day 25 part 2"},
+	}
+	for _, test := range tests {
+		status, body := request(t, server, "POST", test.path, "input")
+		if status != test.status {
+			t.Errorf("%s: status %d, want %d: %v", test.path, status, test.status, body)
+		}
+		if test.errorText != "" && !strings.Contains(fmt.Sprint(body["error"]), test.errorText) {
+			t.Errorf("%s: error %q, want it to contain %q", test.path, body["error"], test.errorText)
+		}
+	}
+}
+
+var printAnswer = regexp.MustCompile(`aoc\.PrintAnswer\((\d),`)
+
+// The parts Discover registers are the ones each day prints.
+func TestDayParts(t *testing.T) {
+	sources, err := filepath.Glob("../../day[0-9][0-9]/day[0-9][0-9].go")
+	if err != nil || len(sources) == 0 {
+		t.Fatalf("no day sources: %v", err)
+	}
+	for _, source := range sources {
+		text, err := os.ReadFile(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var printed []int
+		for _, match := range printAnswer.FindAllSubmatch(text, -1) {
+			printed = append(printed, int(match[1][0]-'0'))
+		}
+
+		var day int
+		fmt.Sscanf(filepath.Base(source), "day%d.go", &day)
+		parts, found := dayParts[day]
+		if !found {
+			parts = []int{1, 2}
+		}
+		if !reflect.DeepEqual(printed, parts) {
+			t.Errorf("day %d prints parts %v, but is registered for %v", day, printed, parts)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package sim
+
+// Stepper is a world which can be advanced one step at a time.
+type Stepper interface {
+	// Step advances the world by one step, reporting whether anything
+	// changed.
+	Step() bool
+}
+
+// Fingerprinter identifies the current state of a world. Two states with the
+// same fingerprint must evolve identically from then on.
+type Fingerprinter[F comparable] interface {
+	Fingerprint() F
+}
+
+// Snapshotter can capture the state of a world and later return to it.
+type Snapshotter[S any] interface {
+	Snapshot() S
+	Restore(S)
+}
+
+type Simulation[F comparable, S any] interface {
+	Stepper
+	Fingerprinter[F]
+	Snapshotter[S]
+}
+
+type Cyclic[F comparable] interface {
+	Stepper
+	Fingerprinter[F]
+}
+
+// Cycle describes a world which, from step Start on, repeats every Length
+// steps.
+type Cycle struct {
+	Start, Length int
+}
+
+// Run advances s by n steps, stopping early if a step changes nothing. It
+// returns the number of steps taken, including that last one.
+func Run(s Stepper, n int) int {
+	for i := 0; i < n; i++ {
+		if !s.Step() {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// RunUntilStable advances s until a step changes nothing, and returns the
+// number of steps taken including that one. It gives up after limit steps
+// unless limit is zero.
+func RunUntilStable(s Stepper, limit int) (int, bool) {
+	for i := 0; limit == 0 || i < limit; i++ {
+		if !s.Step() {
+			return i + 1, true
+		}
+	}
+	return limit, false
+}
+
+// FindCycle advances s until its fingerprints repeat, using Brent's
+// algorithm to find the cycle length. If observe is not nil it is called
+// before the first step and after every step, with the number of steps taken
+// so far. It gives up after limit steps unless limit is zero.
+func FindCycle[F comparable](s Cyclic[F], limit int, observe func(step int)) (Cycle, bool) {
+	history := make([]F, 0)
+	return findCycle(s, &history, limit, observe)
+}
+
+// findCycle carries on from the fingerprints in history, which it extends
+// as it steps s. The search is replayed over the steps already taken, so
+// given the same history it ends up where it left off.
+func findCycle[F comparable](s Cyclic[F], history *[]F, limit int, observe func(step int)) (Cycle, bool) {
+	if observe == nil {
+		observe = func(int) {}
+	}
+
+	if len(*history) == 0 {
+		*history = append(*history, s.Fingerprint())
+		observe(0)
+	}
+
+	pos := 0
+	advance := func() bool {
+		if pos+1 < len(*history) {
+			pos++
+			return true
+		}
+		if limit != 0 && len(*history) > limit {
+			return false
+		}
+		s.Step()
+		*history = append(*history, s.Fingerprint())
+		pos++
+		observe(pos)
+		return true
+	}
+
+	// Find the cycle length: the hare runs ahead, and the tortoise teleports
+	// to it at every power of two.
+	power, length := 1, 1
+	tortoise := (*history)[0]
+	if !advance() {
+		return Cycle{}, false
+	}
+	for tortoise != (*history)[pos] {
+		if power == length {
+			tortoise = (*history)[pos]
+			power *= 2
+			length = 0
+		}
+		if !advance() {
+			return Cycle{}, false
+		}
+		length++
+	}
+
+	// The history holds the whole run, so the start of the cycle is the
+	// first step which matches the one a cycle length later.
+	start := 0
+	for (*history)[start] != (*history)[start+length] {
+		start++
+	}
+
+	return Cycle{start, length}, true
+}
+
+// Trace is the fingerprint and measurement of a world before the first step
+// and after each one since, which is all Extrapolate needs to carry on from
+// where it left off.
+type Trace[F comparable] struct {
+	Fingerprints []F
+	Measurements []int
+}
+
+// Extrapolate predicts the value of measure after target steps, by running
+// s until it cycles and assuming measure changes by the same amount every
+// time around the cycle.
+func Extrapolate[F comparable](s Cyclic[F], target int, limit int, measure func() int) (int, bool) {
+	return ExtrapolateFrom[F](s, &Trace[F]{}, target, limit, measure, nil)
+}
+
+// ExtrapolateFrom is Extrapolate carrying on from trace, with s in the state
+// after the last step traced. The trace is extended as s is stepped, and
+// observe, if not nil, is called after each step so it can be saved.
+func ExtrapolateFrom[F comparable](s Cyclic[F], trace *Trace[F], target int, limit int, measure func() int, observe func()) (int, bool) {
+	cycle, found := findCycle[F](s, &trace.Fingerprints, limit, func(step int) {
+		trace.Measurements = append(trace.Measurements, measure())
+		if observe != nil {
+			observe()
+		}
+	})
+
+	measurements := trace.Measurements
+	if target < len(measurements) {
+		return measurements[target], true
+	}
+	if !found {
+		return 0, false
+	}
+
+	loops := (target - cycle.Start) / cycle.Length
+	offset := (target - cycle.Start) % cycle.Length
+	perLoop := measurements[cycle.Start+cycle.Length] - measurements[cycle.Start]
+
+	return measurements[cycle.Start+offset] + loops*perLoop, true
+}
+
+// Recording holds a snapshot of a world before each step, for replay.
+type Recording[S any] struct {
+	snapshots []S
+}
+
+// Record captures s, then advances it up to n steps capturing it after
+// each, stopping early if a step changes nothing.
+func Record[S any](s interface {
+	Stepper
+	Snapshotter[S]
+}, n int) *Recording[S] {
+	recording := &Recording[S]{[]S{s.Snapshot()}}
+	for i := 0; i < n; i++ {
+		changed := s.Step()
+		recording.snapshots = append(recording.snapshots, s.Snapshot())
+		if !changed {
+			break
+		}
+	}
+	return recording
+}
+
+// Len is the number of snapshots, which is one more than the steps recorded.
+func (this *Recording[S]) Len() int {
+	return len(this.snapshots)
+}
+
+func (this *Recording[S]) At(step int) S {
+	return this.snapshots[step]
+}
+
+// Append captures s as the next step of the recording.
+func (this *Recording[S]) Append(s Snapshotter[S]) {
+	this.snapshots = append(this.snapshots, s.Snapshot())
+}
+
+// Replay restores s to how it was after the given number of steps.
+func (this *Recording[S]) Replay(s Snapshotter[S], step int) {
+	s.Restore(this.snapshots[step])
+}
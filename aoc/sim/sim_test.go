@@ -0,0 +1,279 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+)
+
+// lasso runs along a tail of states into a loop of them, adding
+// each state it reaches to a running total. After step k it is in state k
+// until it reaches the loop, so the cycle starts at step tail.
+type lasso struct {
+	tail, loop   int
+	state, total int
+	steps        int
+}
+
+func (this *lasso) Step() bool {
+	this.state++
+	if this.state == this.tail+this.loop {
+		this.state = this.tail
+	}
+	this.total += this.state
+	this.steps++
+	return true
+}
+
+func (this *lasso) Fingerprint() int {
+	return this.state
+}
+
+type lassoSnapshot struct {
+	state, total int
+}
+
+func (this *lasso) Snapshot() lassoSnapshot {
+	return lassoSnapshot{this.state, this.total}
+}
+
+func (this *lasso) Restore(snapshot lassoSnapshot) {
+	this.state, this.total = snapshot.state, snapshot.total
+}
+
+func (this *lasso) measure() int {
+	return this.total
+}
+
+// totalAfter works out the lasso's total after n steps without running it.
+func totalAfter(tail, loop, n int) int {
+	if n < tail+loop {
+		return n * (n + 1) / 2
+	}
+	// Steps 1 to tail-1 are on the tail; the rest go round the loop.
+	total := (tail - 1) * tail / 2
+	inLoop := n - tail + 1
+	full, rest := inLoop/loop, inLoop%loop
+	total += full * (loop*tail + loop*(loop-1)/2)
+	total += rest*tail + rest*(rest-1)/2
+	return total
+}
+
+var lassos = []struct {
+	tail, loop int
+}{
+	{0, 1},
+	{0, 5},
+	{1, 1},
+	{3, 4},
+	{7, 2},
+	{10, 13},
+	{64, 1},
+	{5, 64},
+}
+
+func TestTotalAfter(t *testing.T) {
+	for _, shape := range lassos {
+		s := &lasso{tail: shape.tail, loop: shape.loop}
+		for n := 0; n < 3*(shape.tail+shape.loop); n++ {
+			if got := totalAfter(shape.tail, shape.loop, n); got != s.total {
+				t.Fatalf("lasso %v: totalAfter(%d) = %d, want %d", shape, n, got, s.total)
+			}
+			s.Step()
+		}
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	for _, shape := range lassos {
+		s := &lasso{tail: shape.tail, loop: shape.loop}
+
+		var observed []int
+		cycle, found := FindCycle[int](s, 0, func(step int) {
+			observed = append(observed, step)
+		})
+		if !found || cycle != (Cycle{shape.tail, shape.loop}) {
+			t.Errorf("lasso %v: FindCycle = %v, %v", shape, cycle, found)
+		}
+
+		// It can't tell where the cycle is before going round it once.
+		if s.steps < shape.tail+shape.loop {
+			t.Errorf("lasso %v: found a cycle after only %d steps", shape, s.steps)
+		}
+		for k, step := range observed {
+			if step != k {
+				t.Fatalf("lasso %v: observed steps %v", shape, observed)
+			}
+		}
+		if len(observed) != s.steps+1 {
+			t.Errorf("lasso %v: observed %d times in %d steps", shape, len(observed), s.steps)
+		}
+	}
+}
+
+func TestFindCycleLimit(t *testing.T) {
+	s := &lasso{tail: 10, loop: 10}
+	if cycle, found := FindCycle[int](s, 15, nil); found {
+		t.Errorf("found %v within a limit shorter than the cycle", cycle)
+	}
+	if s.steps > 15 {
+		t.Errorf("took %d steps with a limit of 15", s.steps)
+	}
+}
+
+func TestExtrapolate(t *testing.T) {
+	for _, shape := range lassos {
+		tail, loop := shape.tail, shape.loop
+		targets := []int{0, 1, tail, tail + 1, tail + loop - 1, tail + loop, tail + loop + 1, tail + 2*loop, tail + 7*loop + 3, 1e12, 1e15 + 7}
+
+		for _, target := range targets {
+			s := &lasso{tail: tail, loop: loop}
+			got, ok := Extrapolate[int](s, target, 0, s.measure)
+			if want := totalAfter(tail, loop, target); !ok || got != want {
+				t.Errorf("lasso %v: total after %d = %d, %v; want %d", shape, target, got, ok, want)
+			}
+		}
+	}
+}
+
+// Stopping part way and carrying on from the trace gives the same answer as
+// running straight through, without repeating any steps.
+func TestExtrapolateFrom(t *testing.T) {
+	const target = 1000000
+	want := totalAfter(10, 13, target)
+
+	for _, limit := range []int{1, 5, 10, 15, 22} {
+		s := &lasso{tail: 10, loop: 13}
+		var trace Trace[int]
+		if _, ok := ExtrapolateFrom[int](s, &trace, target, limit, s.measure, nil); ok {
+			t.Errorf("limit %d: extrapolated before finding the cycle", limit)
+		}
+		if len(trace.Fingerprints) != s.steps+1 || len(trace.Measurements) != s.steps+1 {
+			t.Fatalf("limit %d: traced %d fingerprints and %d measurements after %d steps",
+				limit, len(trace.Fingerprints), len(trace.Measurements), s.steps)
+		}
+		before := s.steps
+
+		observed := 0
+		got, ok := ExtrapolateFrom[int](s, &trace, target, 0, s.measure, func() { observed++ })
+		if !ok || got != want {
+			t.Errorf("limit %d: resumed total = %d, %v; want %d", limit, got, ok, want)
+		}
+		if observed != s.steps-before {
+			t.Errorf("limit %d: observed %d times in %d new steps", limit, observed, s.steps-before)
+		}
+
+		straight := &lasso{tail: 10, loop: 13}
+		FindCycle[int](straight, 0, nil)
+		if s.steps != straight.steps {
+			t.Errorf("limit %d: took %d steps in all, want %d", limit, s.steps, straight.steps)
+		}
+	}
+}
+
+// A target within the steps already taken is read from the trace, even if
+// no cycle was found.
+func TestExtrapolateWithinLimit(t *testing.T) {
+	s := &lasso{tail: 100, loop: 100}
+	got, ok := Extrapolate[int](s, 20, 50, s.measure)
+	if want := totalAfter(100, 100, 20); !ok || got != want {
+		t.Errorf("total after 20 = %d, %v; want %d", got, ok, want)
+	}
+	s = &lasso{tail: 100, loop: 100}
+	if _, ok := Extrapolate[int](s, 60, 50, s.measure); ok {
+		t.Error("extrapolated past the limit without a cycle")
+	}
+}
+
+// stopper changes nothing once it has counted down to zero.
+type stopper struct {
+	left, steps int
+}
+
+func (this *stopper) Step() bool {
+	this.steps++
+	if this.left == 0 {
+		return false
+	}
+	this.left--
+	return true
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		left, n, steps int
+	}{
+		{5, 3, 3},
+		{5, 5, 5},
+		{5, 10, 6},
+		{0, 10, 1},
+		{5, 0, 0},
+	}
+	for _, test := range tests {
+		s := &stopper{left: test.left}
+		if got := Run(s, test.n); got != test.steps || s.steps != test.steps {
+			t.Errorf("Run(%d) with %d left = %d after %d steps, want %d", test.n, test.left, got, s.steps, test.steps)
+		}
+	}
+}
+
+func TestRunUntilStable(t *testing.T) {
+	tests := []struct {
+		left, limit, steps int
+		stable             bool
+	}{
+		{5, 0, 6, true},
+		{5, 6, 6, true},
+		{5, 5, 5, false},
+		{0, 0, 1, true},
+	}
+	for _, test := range tests {
+		s := &stopper{left: test.left}
+		steps, stable := RunUntilStable(s, test.limit)
+		if steps != test.steps || stable != test.stable || s.steps != test.steps {
+			t.Errorf("%d left, limit %d: %d, %v after %d steps; want %d, %v",
+				test.left, test.limit, steps, stable, s.steps, test.steps, test.stable)
+		}
+	}
+}
+
+func TestRecording(t *testing.T) {
+	s := &lasso{tail: 2, loop: 3}
+	recording := Record[lassoSnapshot](s, 6)
+	if recording.Len() != 7 {
+		t.Fatalf("Len() = %d, want 7", recording.Len())
+	}
+
+	var states []int
+	for step := 0; step < recording.Len(); step++ {
+		states = append(states, recording.At(step).state)
+	}
+	if want := []int{0, 1, 2, 3, 4, 2, 3}; !reflect.DeepEqual(states, want) {
+		t.Errorf("recorded states %v, want %v", states, want)
+	}
+
+	recording.Replay(s, 3)
+	if s.state != 3 || s.total != totalAfter(2, 3, 3) {
+		t.Errorf("replayed to state %d with total %d", s.state, s.total)
+	}
+
+	// Carrying on from a replayed step is the same as having got there
+	// directly.
+	s.Step()
+	recording.Append(s)
+	if got, want := recording.At(7), recording.At(4); got != want {
+		t.Errorf("stepping on from step 3 gives %v, want %v", got, want)
+	}
+
+	stopped := Record[int](&stopper{left: 2}, 10)
+	if stopped.Len() != 4 {
+		t.Errorf("recorded %d snapshots of a stopper with 2 steps left, want 4", stopped.Len())
+	}
+}
+
+func (this *stopper) Snapshot() int {
+	return this.left
+}
+
+func (this *stopper) Restore(left int) {
+	this.left = left
+}
@@ -0,0 +1,224 @@
+package aoc
+
+import (
+	"fmt"
+)
+
+// Dir is a direction on a grid, where y increases downwards. They go round
+// clockwise, so turning is adding.
+type Dir int
+
+const (
+	East Dir = iota
+	South
+	West
+	North
+)
+
+// Topology decides where a step from a cell leads, and which way it is then
+// facing. ok is false if the step leads nowhere.
+type Topology interface {
+	Step(x, y int, dir Dir) (nx, ny int, ndir Dir, ok bool)
+}
+
+// Planar is a plain rectangle: stepping off an edge goes nowhere.
+type Planar struct {
+	w, h int
+}
+
+// Torus is a rectangle where stepping off an edge comes back in at the
+// opposite edge.
+type Torus struct {
+	w, h int
+}
+
+// RaggedWrap is a grid where only some cells are present, in one unbroken
+// run along each row and column. Stepping off the end of a run comes back in
+// at its other end.
+type RaggedWrap struct {
+	rows, cols []span
+}
+
+type span struct {
+	start, end int // inclusive; start > end if empty
+}
+
+// Glued is another topology with some of its edges joined together, as when
+// folding a net into a cube. Stepping across a glued edge follows the seam;
+// everywhere else the base topology applies.
+type Glued struct {
+	base  Topology
+	seams map[gluePoint]gluePoint
+}
+
+// Edge is a line of cells, starting from the cell at X, Y and continuing in
+// direction Along. The edge is on the Out side of those cells.
+type Edge struct {
+	X, Y   int
+	Along  Dir
+	Out    Dir
+	Length int
+}
+
+type gluePoint struct {
+	x, y int
+	dir  Dir
+}
+
+var deltas = [...][2]int{
+	East:  {1, 0},
+	South: {0, 1},
+	West:  {-1, 0},
+	North: {0, -1},
+}
+
+var dirNames = [...]string{"east", "south", "west", "north"}
+
+func (this Dir) Delta() (dx, dy int) {
+	return deltas[this][0], deltas[this][1]
+}
+
+// Turn turns clockwise by n quarter turns, or anticlockwise if n < 0.
+func (this Dir) Turn(n int) Dir {
+	return Dir(((int(this)+n)%4 + 4) % 4)
+}
+
+func (this Dir) Reverse() Dir {
+	return this.Turn(2)
+}
+
+func (this Dir) String() string {
+	return dirNames[this]
+}
+
+func NewPlanar(w, h int) Planar {
+	return Planar{w, h}
+}
+
+func (this Planar) Step(x, y int, dir Dir) (int, int, Dir, bool) {
+	dx, dy := dir.Delta()
+	x, y = x+dx, y+dy
+	if x < 0 || y < 0 || x >= this.w || y >= this.h {
+		return x, y, dir, false
+	}
+	return x, y, dir, true
+}
+
+func NewTorus(w, h int) Torus {
+	return Torus{w, h}
+}
+
+func (this Torus) Step(x, y int, dir Dir) (int, int, Dir, bool) {
+	dx, dy := dir.Delta()
+	return (x + dx + this.w) % this.w, (y + dy + this.h) % this.h, dir, true
+}
+
+// NewRaggedWrap finds the cells of the grid which are present. A row or
+// column whose present cells have a gap in them can't wrap sensibly, so is
+// an error.
+func NewRaggedWrap[T any](grid *Grid[T], present func(T) bool) (*RaggedWrap, error) {
+	this := &RaggedWrap{
+		rows: make([]span, grid.Height()),
+		cols: make([]span, grid.Width()),
+	}
+
+	for y := range this.rows {
+		s, err := findSpan(grid.Width(), func(x int) bool { return present(grid.Get(x, y)) })
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", y, err)
+		}
+		this.rows[y] = s
+	}
+	for x := range this.cols {
+		s, err := findSpan(grid.Height(), func(y int) bool { return present(grid.Get(x, y)) })
+		if err != nil {
+			return nil, fmt.Errorf("column %d: %w", x, err)
+		}
+		this.cols[x] = s
+	}
+	return this, nil
+}
+
+func findSpan(n int, present func(int) bool) (span, error) {
+	i := 0
+	for i < n && !present(i) {
+		i++
+	}
+	s := span{i, i - 1}
+	for i < n && present(i) {
+		s.end = i
+		i++
+	}
+	for ; i < n; i++ {
+		if present(i) {
+			return s, fmt.Errorf("gap before %d", i)
+		}
+	}
+	return s, nil
+}
+
+func (this span) contains(i int) bool {
+	return i >= this.start && i <= this.end
+}
+
+// wrap brings i, no more than one step outside the span, back in from the
+// other end.
+func (this span) wrap(i int) int {
+	switch {
+	case i < this.start:
+		return this.end
+	case i > this.end:
+		return this.start
+	default:
+		return i
+	}
+}
+
+// Step goes nowhere from a cell which isn't present.
+func (this *RaggedWrap) Step(x, y int, dir Dir) (int, int, Dir, bool) {
+	if y < 0 || y >= len(this.rows) || !this.rows[y].contains(x) {
+		return x, y, dir, false
+	}
+
+	dx, dy := dir.Delta()
+	if dx != 0 {
+		return this.rows[y].wrap(x + dx), y, dir, true
+	}
+	return x, this.cols[x].wrap(y + dy), dir, true
+}
+
+func NewGlued(base Topology) *Glued {
+	return &Glued{base, make(map[gluePoint]gluePoint)}
+}
+
+// Glue joins two edges of the same length, cell by cell, in both directions.
+// Stepping out across one edge arrives at the matching cell of the other,
+// facing away from it. If reversed, the first cell of a meets the last of b.
+func (this *Glued) Glue(a, b Edge, reversed bool) error {
+	if a.Length != b.Length {
+		return fmt.Errorf("can't glue an edge of %d cells to one of %d", a.Length, b.Length)
+	}
+
+	for i := 0; i < a.Length; i++ {
+		j := i
+		if reversed {
+			j = b.Length - 1 - i
+		}
+		from, to := a.cell(i), b.cell(j)
+		this.seams[gluePoint{from.x, from.y, a.Out}] = gluePoint{to.x, to.y, b.Out.Reverse()}
+		this.seams[gluePoint{to.x, to.y, b.Out}] = gluePoint{from.x, from.y, a.Out.Reverse()}
+	}
+	return nil
+}
+
+func (this Edge) cell(i int) gluePoint {
+	dx, dy := this.Along.Delta()
+	return gluePoint{this.X + i*dx, this.Y + i*dy, this.Out}
+}
+
+func (this *Glued) Step(x, y int, dir Dir) (int, int, Dir, bool) {
+	if to, found := this.seams[gluePoint{x, y, dir}]; found {
+		return to.x, to.y, to.dir, true
+	}
+	return this.base.Step(x, y, dir)
+}
@@ -0,0 +1,293 @@
+package aoc
+
+import (
+	"testing"
+)
+
+type position struct {
+	x, y int
+	dir  Dir
+}
+
+func step(topology Topology, from position) (position, bool) {
+	x, y, dir, ok := topology.Step(from.x, from.y, from.dir)
+	return position{x, y, dir}, ok
+}
+
+func TestPlanar(t *testing.T) {
+	planar := NewPlanar(3, 2)
+	tests := []struct {
+		from position
+		to   position
+		ok   bool
+	}{
+		{position{0, 0, East}, position{1, 0, East}, true},
+		{position{1, 1, North}, position{1, 0, North}, true},
+		{position{2, 0, East}, position{}, false},
+		{position{0, 1, West}, position{}, false},
+		{position{1, 0, North}, position{}, false},
+		{position{1, 1, South}, position{}, false},
+	}
+	for _, test := range tests {
+		to, ok := step(planar, test.from)
+		if ok != test.ok || (ok && to != test.to) {
+			t.Errorf("step from %v = %v, %v; want %v, %v", test.from, to, ok, test.to, test.ok)
+		}
+	}
+}
+
+func TestTorus(t *testing.T) {
+	torus := NewTorus(3, 2)
+	tests := []struct {
+		from, to position
+	}{
+		{position{0, 0, East}, position{1, 0, East}},
+		{position{2, 0, East}, position{0, 0, East}},
+		{position{0, 1, West}, position{2, 1, West}},
+		{position{1, 0, North}, position{1, 1, North}},
+		{position{1, 1, South}, position{1, 0, South}},
+	}
+	for _, test := range tests {
+		to, ok := step(torus, test.from)
+		if !ok || to != test.to {
+			t.Errorf("step from %v = %v, %v; want %v", test.from, to, ok, test.to)
+		}
+	}
+}
+
+// The flat map from the day 22 example.
+var raggedExample = []string{
+	"        ...#",
+	"        .#..",
+	"        #...",
+	"        ....",
+	"...#.......#",
+	"........#...",
+	"..#....#....",
+	"..........#.",
+	"        ...#....",
+	"        .....#..",
+	"        .#......",
+	"        ......#.",
+}
+
+func raggedGrid(lines []string) *Grid[rune] {
+	width := 0
+	for _, line := range lines {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+	grid := NewGrid(width, len(lines), ' ')
+	for y, line := range lines {
+		for x, c := range line {
+			grid.Set(x, y, c)
+		}
+	}
+	return grid
+}
+
+func TestRaggedWrap(t *testing.T) {
+	ragged, err := NewRaggedWrap(raggedGrid(raggedExample), func(c rune) bool {
+		return c != ' '
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to position
+		ok       bool
+	}{
+		{position{9, 0, East}, position{10, 0, East}, true},
+		{position{11, 0, East}, position{8, 0, East}, true},
+		{position{8, 2, West}, position{11, 2, West}, true},
+		{position{0, 5, West}, position{11, 5, West}, true},
+		{position{15, 9, East}, position{8, 9, East}, true},
+		{position{8, 0, North}, position{8, 11, North}, true},
+		{position{0, 7, South}, position{0, 4, South}, true},
+		{position{5, 4, North}, position{5, 7, North}, true},
+		{position{14, 11, South}, position{14, 8, South}, true},
+		{position{0, 0, East}, position{}, false},
+	}
+	for _, test := range tests {
+		to, ok := step(ragged, test.from)
+		if ok != test.ok || (ok && to != test.to) {
+			t.Errorf("step from %v = %v, %v; want %v, %v", test.from, to, ok, test.to, test.ok)
+		}
+	}
+
+	gapped := raggedGrid([]string{"..", "  ", ".."})
+	if _, err := NewRaggedWrap(gapped, func(c rune) bool { return c != ' ' }); err == nil {
+		t.Error("no error for a column with a gap")
+	}
+}
+
+// seam is a pair of glued cube edges: stepping from start(i) in dir arrives
+// at to(i) facing toDir, for every i along the edge.
+type seam struct {
+	name  string
+	start func(i int) (int, int)
+	dir   Dir
+	to    func(i int) (int, int)
+	toDir Dir
+}
+
+type cubeTest struct {
+	name   string
+	size   int // of each face
+	w, h   int
+	glue   [][2]Edge // glued in reverse, as every seam of these nets is
+	seams  []seam
+	inside []position // steps which cross no seam
+}
+
+var cubeTests = []cubeTest{
+	{
+		// The example net, where face 1 is above 4, with 2, 3, 4 across
+		// the middle and 5, 6 below.
+		//
+		//	    1
+		//	2 3 4
+		//	    5 6
+		name: "example",
+		size: 4,
+		w:    16,
+		h:    12,
+		glue: [][2]Edge{
+			{{8, 0, East, North, 4}, {0, 4, East, North, 4}},
+			{{8, 0, South, West, 4}, {7, 4, West, North, 4}},
+			{{11, 0, South, East, 4}, {15, 8, South, East, 4}},
+			{{0, 4, South, West, 4}, {12, 11, East, South, 4}},
+			{{0, 7, East, South, 4}, {8, 11, East, South, 4}},
+			{{4, 7, East, South, 4}, {8, 8, South, West, 4}},
+			{{11, 4, South, East, 4}, {12, 8, East, North, 4}},
+		},
+		seams: []seam{
+			{"1 north to 2 north", func(i int) (int, int) { return 8 + i, 0 }, North, func(i int) (int, int) { return 3 - i, 4 }, South},
+			{"1 west to 3 north", func(i int) (int, int) { return 8, i }, West, func(i int) (int, int) { return 4 + i, 4 }, South},
+			{"1 east to 6 east", func(i int) (int, int) { return 11, i }, East, func(i int) (int, int) { return 15, 11 - i }, West},
+			{"2 west to 6 south", func(i int) (int, int) { return 0, 4 + i }, West, func(i int) (int, int) { return 15 - i, 11 }, North},
+			{"2 south to 5 south", func(i int) (int, int) { return i, 7 }, South, func(i int) (int, int) { return 11 - i, 11 }, North},
+			{"3 south to 5 west", func(i int) (int, int) { return 4 + i, 7 }, South, func(i int) (int, int) { return 8, 11 - i }, East},
+			{"4 east to 6 north", func(i int) (int, int) { return 11, 4 + i }, East, func(i int) (int, int) { return 15 - i, 8 }, South},
+		},
+		inside: []position{
+			{8, 3, South},
+			{3, 5, East},
+			{11, 8, East},
+			{9, 10, West},
+		},
+	},
+	{
+		// The shape of the real input's net.
+		//
+		//	  A B
+		//	  C
+		//	D E
+		//	F
+		name: "input",
+		size: 50,
+		w:    150,
+		h:    200,
+		glue: [][2]Edge{
+			{{50, 0, East, North, 50}, {0, 199, North, West, 50}},
+			{{50, 0, South, West, 50}, {0, 100, South, West, 50}},
+			{{100, 0, East, North, 50}, {49, 199, West, South, 50}},
+			{{149, 0, South, East, 50}, {99, 100, South, East, 50}},
+			{{100, 49, East, South, 50}, {99, 99, North, East, 50}},
+			{{50, 50, South, West, 50}, {49, 100, West, North, 50}},
+			{{50, 149, East, South, 50}, {49, 199, North, East, 50}},
+		},
+		seams: []seam{
+			{"A north to F west", func(i int) (int, int) { return 50 + i, 0 }, North, func(i int) (int, int) { return 0, 150 + i }, East},
+			{"A west to D west", func(i int) (int, int) { return 50, i }, West, func(i int) (int, int) { return 0, 149 - i }, East},
+			{"B north to F south", func(i int) (int, int) { return 100 + i, 0 }, North, func(i int) (int, int) { return i, 199 }, North},
+			{"B east to E east", func(i int) (int, int) { return 149, i }, East, func(i int) (int, int) { return 99, 149 - i }, West},
+			{"B south to C east", func(i int) (int, int) { return 100 + i, 49 }, South, func(i int) (int, int) { return 99, 50 + i }, West},
+			{"C west to D north", func(i int) (int, int) { return 50, 50 + i }, West, func(i int) (int, int) { return i, 100 }, South},
+			{"E south to F east", func(i int) (int, int) { return 50 + i, 149 }, South, func(i int) (int, int) { return 49, 150 + i }, West},
+		},
+		inside: []position{
+			{99, 10, East},
+			{60, 49, South},
+			{60, 99, South},
+			{49, 120, East},
+			{10, 149, South},
+		},
+	},
+}
+
+func TestGluedCube(t *testing.T) {
+	for _, test := range cubeTests {
+		cube := NewGlued(NewPlanar(test.w, test.h))
+		for _, edges := range test.glue {
+			if err := cube.Glue(edges[0], edges[1], true); err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+		}
+
+		// Walk every cell of each seam, there and back.
+		for _, seam := range test.seams {
+			for i := 0; i < test.size; i++ {
+				x, y := seam.start(i)
+				from := position{x, y, seam.dir}
+				x, y = seam.to(i)
+				want := position{x, y, seam.toDir}
+
+				to, ok := step(cube, from)
+				if !ok || to != want {
+					t.Errorf("%s: %s: step from %v = %v, %v; want %v", test.name, seam.name, from, to, ok, want)
+					continue
+				}
+
+				back, ok := step(cube, position{to.x, to.y, to.dir.Reverse()})
+				if want := (position{from.x, from.y, from.dir.Reverse()}); !ok || back != want {
+					t.Errorf("%s: %s: step back from %v = %v, %v; want %v", test.name, seam.name, to, back, ok, want)
+				}
+			}
+		}
+
+		for _, from := range test.inside {
+			dx, dy := from.dir.Delta()
+			want := position{from.x + dx, from.y + dy, from.dir}
+			if to, ok := step(cube, from); !ok || to != want {
+				t.Errorf("%s: step from %v = %v, %v; want %v", test.name, from, to, ok, want)
+			}
+		}
+	}
+}
+
+func TestGlueLengths(t *testing.T) {
+	cube := NewGlued(NewPlanar(8, 8))
+	if err := cube.Glue(Edge{0, 0, East, North, 4}, Edge{0, 0, South, West, 3}, false); err == nil {
+		t.Error("no error gluing edges of different lengths")
+	}
+}
+
+func TestDir(t *testing.T) {
+	tests := []struct {
+		dir     Dir
+		turn    int
+		turned  Dir
+		reverse Dir
+		name    string
+	}{
+		{East, 1, South, West, "east"},
+		{South, -1, East, North, "south"},
+		{West, 2, East, East, "west"},
+		{North, 1, East, South, "north"},
+		{North, -5, West, South, "north"},
+	}
+	for _, test := range tests {
+		if got := test.dir.Turn(test.turn); got != test.turned {
+			t.Errorf("%v.Turn(%d) = %v, want %v", test.dir, test.turn, got, test.turned)
+		}
+		if got := test.dir.Reverse(); got != test.reverse {
+			t.Errorf("%v.Reverse() = %v, want %v", test.dir, got, test.reverse)
+		}
+		if got := test.dir.String(); got != test.name {
+			t.Errorf("String() = %q, want %q", got, test.name)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"advent-of-code/aoc"
+	"advent-of-code/aoc/batch"
+	"advent-of-code/aoc/leaderboard"
+	"advent-of-code/aoc/serve"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const year = 2022
+
+var commands = map[string]func(args []string){
+	"serve":       serveCommand,
+	"batch":       batchCommand,
+	"leaderboard": leaderboardCommand,
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		usage()
+	}
+	commands[os.Args[1]](os.Args[2:])
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: aoc command [flags] [args]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  serve        answer puzzles over HTTP")
+	fmt.Fprintln(os.Stderr, "  batch        run one day on many inputs")
+	fmt.Fprintln(os.Stderr, "  leaderboard  analyse a saved private leaderboard")
+	os.Exit(2)
+}
+
+func serveCommand(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	root := flags.String("root", ".", "directory containing the built dayNN binaries")
+	timeout := flags.Duration("timeout", serve.DefaultOptions.Timeout, "time limit per request")
+	maxBody := flags.Int64("max-body", serve.DefaultOptions.MaxBody, "input size limit in bytes")
+	flags.Parse(args)
+
+	registry := serve.NewRegistry()
+	aoc.CheckErr(serve.Discover(registry, *root, year))
+	if len(registry.Puzzles()) == 0 {
+		log.Fatalf("no day binaries found under %s; try make build", *root)
+	}
+
+	server := serve.NewServer(registry, serve.Options{Timeout: *timeout, MaxBody: *maxBody})
+	log.Printf("serving %d days on %s", len(registry.Puzzles()), *addr)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
+
+func batchCommand(args []string) {
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	root := flags.String("root", ".", "directory containing the built dayNN binaries")
+	jobs := flags.Int("j", runtime.NumCPU(), "inputs to run at once")
+	timeout := flags.Duration("timeout", 0, "time limit per input, if any")
+	sortBy := flags.String("sort", "input", "order within each group: input or time")
+	groupBy := flags.String("group", "status", "group results by status, dir or none")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: aoc batch [flags] day input-glob-or-dir...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	day, err := strconv.Atoi(strings.TrimPrefix(flags.Arg(0), "day"))
+	if err != nil {
+		log.Fatalf("bad day %q", flags.Arg(0))
+	}
+	programs, err := serve.FindPrograms(*root)
+	aoc.CheckErr(err)
+	program, found := programs[day]
+	if !found {
+		log.Fatalf("no binary for day %d under %s; try make build", day, *root)
+	}
+
+	inputs, err := batch.Inputs(flags.Args()[1:])
+	aoc.CheckErr(err)
+
+	results := batch.Run(context.Background(), program, inputs, *jobs, *timeout)
+	aoc.CheckErr(batch.Sort(results, *sortBy))
+	groups, err := batch.GroupBy(results, *groupBy)
+	aoc.CheckErr(err)
+	aoc.CheckErr(batch.WriteTable(os.Stdout, groups))
+
+	for _, result := range results {
+		if result.Failed() {
+			os.Exit(1)
+		}
+	}
+}
+
+func leaderboardCommand(args []string) {
+	flags := flag.NewFlagSet("leaderboard", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the analysis as JSON")
+	member := flags.String("member", "", "only show members whose names contain this")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: aoc leaderboard [flags] leaderboard.json")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	aoc.CheckErr(err)
+	defer file.Close()
+
+	board, err := leaderboard.Read(file)
+	if err != nil {
+		log.Fatalf("%s: %v", flags.Arg(0), err)
+	}
+
+	report := board.Analyse()
+	if *member != "" {
+		report = report.Only(*member)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		aoc.CheckErr(encoder.Encode(report))
+	} else {
+		aoc.CheckErr(report.WriteText(os.Stdout))
+	}
+}
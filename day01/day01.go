@@ -2,16 +2,59 @@ package main
 
 import (
 	"advent-of-code/aoc"
+	"advent-of-code/aoc/mathx"
+	"container/heap"
+	"flag"
 	"fmt"
+	"log"
+	"os"
 	"sort"
+	"strings"
 )
 
+// Elf is one group of lines in the input. Index counts from 1.
+type Elf struct {
+	index, calories, items int
+}
+
+// elfHeap is a min-heap, so the smallest of the top elves is the one to go.
+type elfHeap []Elf
+
+var topK = flag.Int("k", 3, "how many elves to total in part 2")
+var report = flag.Bool("report", false, "list the top k elves on stderr")
+var groups = flag.Int("groups", 0, "instead of the puzzle, split the elves into this many groups carrying as near equal calories as possible")
+
+// Partition is the elves split into groups, and the difference between the
+// heaviest and lightest group.
+type Partition struct {
+	groups [][]Elf
+	sums   []int
+	spread int
+	exact  bool
+}
+
+// Above this many elves, finding the best partition could take forever.
+const exactLimit = 20
+
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
+	if *topK < 1 {
+		log.Fatalf("k must be at least 1, not %d", *topK)
+	}
+
+	if *groups > 0 {
+		aoc.RefuseJSON("-groups")
+		elves := make([]Elf, 0)
+		eachElf(lines, func(elf Elf) {
+			elves = append(elves, elf)
+		})
+		fmt.Print(partition(elves, *groups))
+		return
+	}
 
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
 }
 
 func part1(lines []string) int {
@@ -33,16 +76,280 @@ func part1(lines []string) int {
 }
 
 func part2(lines []string) int {
-	totals := make([]int, 1)
+	top := topElves(lines, *topK)
+	if *report {
+		for _, elf := range top {
+			fmt.Fprintf(os.Stderr, "elf %d: %d calories in %d items\n", elf.index, elf.calories, elf.items)
+		}
+	}
 
+	total := 0
+	for _, elf := range top {
+		total += elf.calories
+	}
+	return total
+}
+
+// eachElf streams the elves from the input. Groups with no items, such as
+// from extra blank lines, aren't elves.
+func eachElf(lines []string, visit func(Elf)) {
+	elf := Elf{index: 1}
 	for _, line := range lines {
 		if line == "" {
-			totals = append(totals, 0)
-		} else {
-			calories := aoc.ParseInt(line)
-			totals[len(totals)-1] += calories
+			if elf.items > 0 {
+				visit(elf)
+				elf = Elf{index: elf.index + 1}
+			}
+			continue
+		}
+		elf.calories += aoc.ParseInt(line)
+		elf.items++
+	}
+	if elf.items > 0 {
+		visit(elf)
+	}
+}
+
+// topElves finds the k elves carrying the most, most first, keeping only k
+// in memory. If there are fewer than k elves, all of them are returned. On a
+// tie the earlier elf wins.
+func topElves(lines []string, k int) []Elf {
+	top := make(elfHeap, 0, k)
+	eachElf(lines, func(elf Elf) {
+		switch {
+		case len(top) < k:
+			heap.Push(&top, elf)
+		case k > 0 && top[0].below(elf):
+			top[0] = elf
+			heap.Fix(&top, 0)
 		}
+	})
+
+	elves := make([]Elf, len(top))
+	for i := len(elves) - 1; i >= 0; i-- {
+		elves[i] = heap.Pop(&top).(Elf)
+	}
+	return elves
+}
+
+// below reports whether this elf ranks lower than that one.
+func (this Elf) below(that Elf) bool {
+	if this.calories != that.calories {
+		return this.calories < that.calories
+	}
+	return this.index > that.index
+}
+
+func (this elfHeap) Len() int           { return len(this) }
+func (this elfHeap) Less(i, j int) bool { return this[i].below(this[j]) }
+func (this elfHeap) Swap(i, j int)      { this[i], this[j] = this[j], this[i] }
+
+func (this *elfHeap) Push(x any) {
+	*this = append(*this, x.(Elf))
+}
+
+func (this *elfHeap) Pop() any {
+	old := *this
+	elf := old[len(old)-1]
+	*this = old[:len(old)-1]
+	return elf
+}
+
+// partition splits the elves into k groups with the smallest spread. Small
+// inputs are searched exhaustively; larger ones get a greedy split, improved
+// by moving and swapping elves between groups.
+func partition(elves []Elf, k int) Partition {
+	sorted := append([]Elf{}, elves...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].calories > sorted[j].calories
+	})
+
+	assignment := greedyPartition(sorted, k)
+	improvePartition(sorted, assignment, k)
+	exact := len(sorted) <= exactLimit
+	if exact {
+		assignment = exactPartition(sorted, k, assignment)
+	}
+
+	result := Partition{groups: make([][]Elf, k), sums: make([]int, k), exact: exact}
+	for i, elf := range sorted {
+		g := assignment[i]
+		result.groups[g] = append(result.groups[g], elf)
+		result.sums[g] += elf.calories
+	}
+	for _, group := range result.groups {
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].index < group[j].index
+		})
+	}
+	result.spread = spread(result.sums)
+	return result
+}
+
+// greedyPartition gives each elf, heaviest first, to the lightest group.
+func greedyPartition(sorted []Elf, k int) []int {
+	assignment := make([]int, len(sorted))
+	sums := make([]int, k)
+	for i, elf := range sorted {
+		lightest := 0
+		for g := range sums {
+			if sums[g] < sums[lightest] {
+				lightest = g
+			}
+		}
+		assignment[i] = lightest
+		sums[lightest] += elf.calories
+	}
+	return assignment
+}
+
+// improvePartition moves single elves, or swaps pairs, between groups for as
+// long as that reduces the spread.
+func improvePartition(sorted []Elf, assignment []int, k int) {
+	sums := make([]int, k)
+	for i, elf := range sorted {
+		sums[assignment[i]] += elf.calories
+	}
+
+	// Changing two groups' sums is an improvement if the spread drops, or
+	// stays the same with the two groups closer together.
+	better := func(a, b, delta int) bool {
+		before, beforeGap := spread(sums), mathx.Abs(sums[a]-sums[b])
+		sums[a] -= delta
+		sums[b] += delta
+		after, afterGap := spread(sums), mathx.Abs(sums[a]-sums[b])
+		sums[a] += delta
+		sums[b] -= delta
+		return after < before || (after == before && afterGap < beforeGap)
+	}
+
+	for improved := true; improved; {
+		improved = false
+		for i := range sorted {
+			for g := 0; g < k && !improved; g++ {
+				from := assignment[i]
+				if g != from && better(from, g, sorted[i].calories) {
+					sums[from] -= sorted[i].calories
+					sums[g] += sorted[i].calories
+					assignment[i] = g
+					improved = true
+				}
+			}
+			for j := i + 1; j < len(sorted) && !improved; j++ {
+				a, b := assignment[i], assignment[j]
+				delta := sorted[i].calories - sorted[j].calories
+				if a != b && delta != 0 && better(a, b, delta) {
+					sums[a] -= delta
+					sums[b] += delta
+					assignment[i], assignment[j] = b, a
+					improved = true
+				}
+			}
+		}
+	}
+}
+
+// exactPartition searches every assignment for the smallest spread, starting
+// from a good one so that most branches can be cut off early.
+func exactPartition(sorted []Elf, k int, start []int) []int {
+	best := append([]int{}, start...)
+	bestSums := make([]int, k)
+	for i, elf := range sorted {
+		bestSums[best[i]] += elf.calories
+	}
+	bestSpread := spread(bestSums)
+
+	remaining := make([]int, len(sorted)+1)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + sorted[i].calories
+	}
+	fair := remaining[0] / k
+	fairCeiling := (remaining[0] + k - 1) / k
+
+	assignment := make([]int, len(sorted))
+	sums := make([]int, k)
+
+	var search func(i int)
+	search = func(i int) {
+		if bestSpread == 0 {
+			return
+		}
+
+		// The heaviest group can only get heavier, and ends up with at least
+		// a fair share. The lightest can end up no heavier than a fair share,
+		// nor than any group given all the rest.
+		heaviest, lowestCeiling := fairCeiling, fair
+		for _, sum := range sums {
+			if sum > heaviest {
+				heaviest = sum
+			}
+			if sum+remaining[i] < lowestCeiling {
+				lowestCeiling = sum + remaining[i]
+			}
+		}
+		if heaviest-lowestCeiling >= bestSpread {
+			return
+		}
+
+		if i == len(sorted) {
+			bestSpread = spread(sums)
+			copy(best, assignment)
+			return
+		}
+
+		for g := range sums {
+			if sameAsEarlier(sums, g) {
+				continue
+			}
+			assignment[i] = g
+			sums[g] += sorted[i].calories
+			search(i + 1)
+			sums[g] -= sorted[i].calories
+		}
+	}
+	search(0)
+
+	return best
+}
+
+// sameAsEarlier reports whether an earlier group has the same sum as group g,
+// in which case giving an elf to either is the same.
+func sameAsEarlier(sums []int, g int) bool {
+	for h := 0; h < g; h++ {
+		if sums[h] == sums[g] {
+			return true
+		}
+	}
+	return false
+}
+
+func spread(sums []int) int {
+	min, max := sums[0], sums[0]
+	for _, sum := range sums {
+		if sum < min {
+			min = sum
+		}
+		if sum > max {
+			max = sum
+		}
+	}
+	return max - min
+}
+
+func (this Partition) String() string {
+	var b strings.Builder
+	for g, group := range this.groups {
+		indexes := make([]string, len(group))
+		for i, elf := range group {
+			indexes[i] = fmt.Sprint(elf.index)
+		}
+		fmt.Fprintf(&b, "group %d: %d calories from elves %s\n", g+1, this.sums[g], strings.Join(indexes, ", "))
+	}
+
+	method := "best possible"
+	if !this.exact {
+		method = "heuristic"
 	}
-	sort.Sort(sort.Reverse(sort.IntSlice(totals)))
-	return totals[0] + totals[1] + totals[2]
+	fmt.Fprintf(&b, "spread: %d (%s)\n", this.spread, method)
+	return b.String()
 }
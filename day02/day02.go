@@ -2,17 +2,22 @@ package main
 
 import (
 	"advent-of-code/aoc"
+	"encoding/csv"
+	"flag"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 )
 
+// Move is one of the moves in the rules, numbered in the order they were
+// given.
 type Move int
 
-const (
-	Rock Move = iota
-	Paper
-	Scissors
-)
-
 type Outcome int
 
 const (
@@ -31,94 +36,765 @@ type Game2 struct {
 	outcome  Outcome
 }
 
+// Rules describe a hand game: the moves, what beats what, what everything
+// scores, and the letters used for them in the strategy guide.
+type Rules struct {
+	moves         []MoveRule
+	outcomeScores [3]int
+
+	// result[you][opponent]
+	result [][]Outcome
+
+	opponentLetters map[byte]Move
+	youLetters      map[byte]Move
+	outcomeLetters  map[byte]Outcome
+}
+
+type MoveRule struct {
+	name  string
+	score int
+}
+
+// The puzzle's own game.
+const rockPaperScissors = `
+move rock     1 A X
+move paper    2 B Y
+move scissors 3 C Z
+outcome lose 0 X
+outcome draw 3 Y
+outcome win  6 Z
+cyclic
+`
+
+var outcomeNames = [...]string{"lose", "draw", "win"}
+
+var rulesFile = flag.String("rules", "", "read the rules of the game from this file, instead of rock paper scissors")
+
+var tablesDir = flag.String("tables", "", "read the rules from move.csv, outcome.csv, round.csv and response.csv in this directory, as used by pg/day02.sql")
+var exportDir = flag.String("export", "", "instead of the puzzle, write the guide and the rules as CSV files for pg/day02.sql into this directory")
+var analyse = flag.Bool("analyse", false, "instead of the puzzle, compare the strategy guide with the best responses to the opponent's moves")
+
 func main() {
 	filename := aoc.GetFilename()
-	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	rules := loadRules(*rulesFile, *tablesDir)
+	lines := readGuide(filename, rules)
+
+	if *exportDir != "" {
+		aoc.CheckErr(exportCSV(*exportDir, rules, lines))
+		return
+	}
+
+	if *analyse {
+		aoc.RefuseJSON("-analyse")
+		analyseGuide(rules, lines)
+		return
+	}
+
+	aoc.PrintAnswer(1, part1(rules, lines))
+	aoc.PrintAnswer(2, part2(rules, lines))
 }
 
-func part1(lines []string) int {
+func part1(rules *Rules, lines []string) int {
 	total := 0
 
 	for _, line := range lines {
-		game := parseGame1(line)
-		total += game.score()
+		game := rules.parseGame1(line)
+		total += rules.score(game)
 	}
 	return total
 }
 
-func part2(lines []string) int {
+func part2(rules *Rules, lines []string) int {
 	total := 0
 
 	for _, line := range lines {
-		game2 := parseGame2(line)
-		game1 := game2.solve()
-		total += game1.score()
+		game2 := rules.parseGame2(line)
+		game1 := rules.solve(game2)
+		total += rules.score(game1)
 	}
 	return total
 }
 
-func RPS(rps Move) string {
-	switch rps {
-	case Rock:
-		return "R1"
-	case Paper:
-		return "P2"
-	case Scissors:
-		return "S3"
-	}
-	return "-"
+func (this *Rules) score(game Game1) int {
+	return this.moves[game.you].score + this.outcomeScores[this.result[game.you][game.opponent]]
+}
+
+// solve picks your move to get the outcome the game asks for.
+func (this *Rules) solve(game2 Game2) Game1 {
+	return Game1{game2.opponent, this.reply(game2.opponent, game2.outcome)}
 }
 
-func (this Game1) score() int {
-	base := this.you.value()
-	if this.you == this.opponent {
-		return 3 + base
+// reply picks the move giving the outcome against the opponent's. In games
+// of more than three moves several may do, and then it is the one scoring
+// the most, or of those the first in the rules, so a winning reply is the
+// best response whenever winning pays.
+func (this *Rules) reply(opponent Move, outcome Outcome) Move {
+	best := Move(-1)
+	for you := range this.moves {
+		if this.result[you][opponent] != outcome {
+			continue
+		}
+		if best == -1 || this.moves[you].score > this.moves[best].score {
+			best = Move(you)
+		}
 	}
-	if this.you.beats() == this.opponent {
-		return 6 + base
+	if best == -1 {
+		panic(fmt.Sprintf("nothing gives %s against %s", outcome, this.name(opponent)))
 	}
-	return base
+	return best
 }
 
-func (this Game2) solve() Game1 {
+func (this *Rules) name(move Move) string {
+	return this.moves[move].name
+}
+
+func (this Outcome) String() string {
+	return outcomeNames[this]
+}
+
+func (this *Rules) parseGame1(line string) Game1 {
 	game := Game1{}
-	game.opponent = this.opponent
+	game.opponent = lookupLetter(this.opponentLetters, line, 0)
+	game.you = lookupLetter(this.youLetters, line, 2)
+	return game
+}
 
-	if this.outcome == Lose {
-		game.you = this.opponent.beats()
-	} else if this.outcome == Win {
-		game.you = this.opponent.losesTo()
-	} else {
-		game.you = this.opponent
-	}
+func (this *Rules) parseGame2(line string) Game2 {
+	game := Game2{}
+	game.opponent = lookupLetter(this.opponentLetters, line, 0)
+	game.outcome = lookupLetter(this.outcomeLetters, line, 2)
 	return game
 }
 
-func (this Move) beats() Move {
-	return (this - 1 + 3) % 3
+func lookupLetter[T any](letters map[byte]T, line string, i int) T {
+	if i >= len(line) {
+		log.Fatalf("%q: too short", line)
+	}
+	value, found := letters[line[i]]
+	if !found {
+		log.Fatalf("%q: unexpected %q", line, line[i])
+	}
+	return value
 }
 
-func (this Move) losesTo() Move {
-	return (this + 1) % 3
+func loadRules(filename, tablesDir string) *Rules {
+	if tablesDir != "" {
+		if filename != "" {
+			log.Fatal("use either -rules or -tables")
+		}
+		rules, err := readTables(tablesDir)
+		aoc.CheckErr(err)
+		return rules
+	}
+
+	text := rockPaperScissors
+	if filename != "" {
+		b, err := os.ReadFile(filename)
+		aoc.CheckErr(err)
+		text = string(b)
+	}
+
+	rules, err := parseRules(text)
+	if err != nil {
+		log.Fatalf("%s: %v", filename, err)
+	}
+	return rules
 }
 
-func (this Move) value() int {
-	return int(this) + 1
+// parseRules reads lines of
+//
+//	move NAME SCORE OPPONENT-LETTER YOUR-LETTER
+//	outcome lose|draw|win SCORE LETTER
+//	beats WINNER LOSER
+//	cyclic
+//
+// where cyclic means each of an odd number of moves beats the half of the
+// others listed just before it, wrapping round: rock beats scissors, paper
+// beats rock, and so on. Blank lines and lines starting with # are ignored.
+//
+// When a guide asks for an outcome which more than one move gives, the
+// highest scoring of them is played, and of those the first listed.
+func parseRules(text string) (*Rules, error) {
+	this := &Rules{
+		opponentLetters: make(map[byte]Move),
+		youLetters:      make(map[byte]Move),
+		outcomeLetters:  make(map[byte]Outcome),
+	}
+	moveIndex := make(map[string]Move)
+	outcomeSeen := [3]bool{}
+	beats := make([][2]string, 0)
+	cyclic := false
+
+	for n, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		fail := func(format string, args ...any) (*Rules, error) {
+			return nil, fmt.Errorf("line %d: %s", n+1, fmt.Sprintf(format, args...))
+		}
+
+		switch {
+		case fields[0] == "move" && len(fields) == 5:
+			name := fields[1]
+			if _, found := moveIndex[name]; found {
+				return fail("move %s again", name)
+			}
+			var score int
+			if _, err := fmt.Sscan(fields[2], &score); err != nil {
+				return fail("bad score %q", fields[2])
+			}
+			move := Move(len(this.moves))
+			moveIndex[name] = move
+			this.moves = append(this.moves, MoveRule{name, score})
+			if err := addLetter(this.opponentLetters, fields[3], move); err != nil {
+				return fail("%v", err)
+			}
+			if err := addLetter(this.youLetters, fields[4], move); err != nil {
+				return fail("%v", err)
+			}
+
+		case fields[0] == "outcome" && len(fields) == 4:
+			outcome, found := parseOutcome(fields[1])
+			if !found {
+				return fail("outcome must be lose, draw or win, not %q", fields[1])
+			}
+			if _, err := fmt.Sscan(fields[2], &this.outcomeScores[outcome]); err != nil {
+				return fail("bad score %q", fields[2])
+			}
+			outcomeSeen[outcome] = true
+			if err := addLetter(this.outcomeLetters, fields[3], outcome); err != nil {
+				return fail("%v", err)
+			}
+
+		case fields[0] == "beats" && len(fields) == 3:
+			beats = append(beats, [2]string{fields[1], fields[2]})
+
+		case fields[0] == "cyclic" && len(fields) == 1:
+			cyclic = true
+
+		default:
+			return fail("can't understand %q", line)
+		}
+	}
+
+	for outcome, seen := range outcomeSeen {
+		if !seen {
+			return nil, fmt.Errorf("no score for %s", Outcome(outcome))
+		}
+	}
+	if len(this.moves) == 0 {
+		return nil, fmt.Errorf("no moves")
+	}
+
+	if cyclic {
+		if len(this.moves)%2 == 0 {
+			return nil, fmt.Errorf("a cyclic game needs an odd number of moves, not %d", len(this.moves))
+		}
+		for i, move := range this.moves {
+			for j := 1; j <= len(this.moves)/2; j++ {
+				loser := this.moves[(i-j+len(this.moves))%len(this.moves)]
+				beats = append(beats, [2]string{move.name, loser.name})
+			}
+		}
+	}
+
+	this.result = newResults(len(this.moves))
+	for _, pair := range beats {
+		winner, found := moveIndex[pair[0]]
+		if !found {
+			return nil, fmt.Errorf("%s beats %s: no move %s", pair[0], pair[1], pair[0])
+		}
+		loser, found := moveIndex[pair[1]]
+		if !found {
+			return nil, fmt.Errorf("%s beats %s: no move %s", pair[0], pair[1], pair[1])
+		}
+		if err := this.setResult(winner, loser, Win); err != nil {
+			return nil, err
+		}
+	}
+	return this, this.check()
 }
 
-func parseGame1(line string) Game1 {
-	game := Game1{}
-	game.opponent = Move(line[0] - 'A')
-	game.you = Move(line[2] - 'X')
-	return game
+func parseOutcome(name string) (Outcome, bool) {
+	for outcome, outcomeName := range outcomeNames {
+		if name == outcomeName {
+			return Outcome(outcome), true
+		}
+	}
+	return 0, false
 }
 
-func parseGame2(line string) Game2 {
-	game := Game2{}
-	game.opponent = Move(line[0] - 'A')
-	game.outcome = Outcome(line[2] - 'X')
-	return game
+func addLetter[T any](letters map[byte]T, letter string, value T) error {
+	if len(letter) != 1 {
+		return fmt.Errorf("letter %q should be a single character", letter)
+	}
+	if _, found := letters[letter[0]]; found {
+		return fmt.Errorf("letter %s used twice", letter)
+	}
+	letters[letter[0]] = value
+	return nil
+}
+
+// newResults starts with every move drawing with itself, and nothing else
+// decided.
+func newResults(n int) [][]Outcome {
+	result := make([][]Outcome, n)
+	for you := range result {
+		result[you] = make([]Outcome, n)
+		for opponent := range result[you] {
+			result[you][opponent] = -1
+		}
+		result[you][you] = Draw
+	}
+	return result
+}
+
+// setResult records the outcome for you against the opponent, and the
+// opposite for the opponent against you.
+func (this *Rules) setResult(you, opponent Move, outcome Outcome) error {
+	if you == opponent && outcome != Draw {
+		return fmt.Errorf("%s can only draw with itself", this.moves[you].name)
+	}
+	if this.result[you][opponent] != -1 && this.result[you][opponent] != outcome {
+		return fmt.Errorf("%s against %s is both %s and %s", this.moves[you].name, this.moves[opponent].name, this.result[you][opponent], outcome)
+	}
+	this.result[you][opponent] = outcome
+	this.result[opponent][you] = Win - outcome
+	return nil
+}
+
+// check makes sure every pair of moves has a winner, and that every move
+// can be beaten and can beat something, so a guide can always be followed.
+func (this *Rules) check() error {
+	for you := range this.result {
+		wins, losses := 0, 0
+		for opponent, outcome := range this.result[you] {
+			switch outcome {
+			case -1:
+				return fmt.Errorf("nothing says what happens with %s against %s", this.moves[you].name, this.moves[opponent].name)
+			case Win:
+				wins++
+			case Lose:
+				losses++
+			}
+		}
+		if wins == 0 || losses == 0 {
+			return fmt.Errorf("%s must beat something and be beaten by something", this.moves[you].name)
+		}
+	}
+	return nil
+}
+
+// MoveStats is how the guide and the best response do against one of the
+// opponent's moves.
+type MoveStats struct {
+	opponent    Move
+	rounds      int
+	best        Move
+	bestScore   int // per round
+	guide1Score int // total, reading the guide as moves
+	guide2Score int // total, reading the guide as outcomes
+}
+
+// analyseGuide works out, for each move the opponent makes, the response
+// scoring the most, and how far short of that the guide falls under either
+// reading of it.
+func analyseGuide(rules *Rules, lines []string) {
+	stats := make([]MoveStats, len(rules.moves))
+	for i := range stats {
+		stats[i].opponent = Move(i)
+		stats[i].best, stats[i].bestScore = rules.bestResponse(Move(i))
+	}
+	for _, line := range lines {
+		game1 := rules.parseGame1(line)
+		s := &stats[game1.opponent]
+		s.rounds++
+		s.guide1Score += rules.score(game1)
+		s.guide2Score += rules.score(rules.solve(rules.parseGame2(line)))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "OPPONENT\tROUNDS\tSHARE\tBEST\tSCORE\tGUIDE AS MOVES\tREGRET\tGUIDE AS OUTCOMES\tREGRET")
+
+	var rounds, best, guide1, guide2 int
+	for _, s := range stats {
+		optimal := s.rounds * s.bestScore
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%s\t%d\t%d\t%d\t%d\t%d\n",
+			rules.name(s.opponent), s.rounds, percent(s.rounds, len(lines)), rules.name(s.best), optimal,
+			s.guide1Score, optimal-s.guide1Score, s.guide2Score, optimal-s.guide2Score)
+		rounds += s.rounds
+		best += optimal
+		guide1 += s.guide1Score
+		guide2 += s.guide2Score
+	}
+	fmt.Fprintf(w, "total\t%d\t\t\t%d\t%d\t%d\t%d\t%d\n", rounds, best, guide1, best-guide1, guide2, best-guide2)
+	aoc.CheckErr(w.Flush())
+
+	if rounds == 0 {
+		return
+	}
+
+	// Against an opponent who picks moves at random, in the same
+	// proportions, what would each fixed move be expected to score?
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ALWAYS PLAY\tEXPECTED PER ROUND")
+	for you := range rules.moves {
+		expected := 0.0
+		for _, s := range stats {
+			expected += float64(s.rounds) / float64(rounds) * float64(rules.score(Game1{s.opponent, Move(you)}))
+		}
+		fmt.Fprintf(w, "%s\t%.3f\n", rules.name(Move(you)), expected)
+	}
+	fmt.Fprintf(w, "best response\t%.3f\n", float64(best)/float64(rounds))
+	fmt.Fprintf(w, "guide as moves\t%.3f\n", float64(guide1)/float64(rounds))
+	fmt.Fprintf(w, "guide as outcomes\t%.3f\n", float64(guide2)/float64(rounds))
+	aoc.CheckErr(w.Flush())
+}
+
+// bestResponse finds the move scoring the most against the opponent's. That
+// isn't always the winning move, if the move scores are lopsided enough.
+func (this *Rules) bestResponse(opponent Move) (Move, int) {
+	best := this.reply(opponent, Win)
+	bestScore := this.score(Game1{opponent, best})
+	for you := range this.moves {
+		if score := this.score(Game1{opponent, Move(you)}); score > bestScore {
+			best, bestScore = Move(you), score
+		}
+	}
+	return best, bestScore
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+// The CSV files are those read by pg/day02.sql. There, "you" is the
+// opponent, "me" is the move to play, and moves and outcomes are spelt out
+// in words.
+var csvHeaders = map[int][]string{
+	1: {"you", "me"},
+	2: {"you", "outcome"},
+}
+
+// readGuide reads the strategy guide from either the puzzle input, or one of
+// the CSV files written by -export. Either CSV holds the whole guide, as it's
+// the same letters read two ways.
+func readGuide(filename string, rules *Rules) []string {
+	if !strings.HasSuffix(filename, ".csv") {
+		return aoc.GetInputLines(filename)
+	}
+
+	file, err := os.Open(filename)
+	aoc.CheckErr(err)
+	defer file.Close()
+
+	lines, err := readGuideCSV(file, rules)
+	if err != nil {
+		log.Fatalf("%s: %v", filename, err)
+	}
+	return lines
+}
+
+func readGuideCSV(r io.Reader, rules *Rules) ([]string, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no header")
+	}
+
+	part := 0
+	for p, header := range csvHeaders {
+		if strings.Join(records[0], ",") == strings.Join(header, ",") {
+			part = p
+		}
+	}
+	if part == 0 {
+		return nil, fmt.Errorf("header should be you,me or you,outcome, not %s", strings.Join(records[0], ","))
+	}
+
+	lines := make([]string, 0, len(records)-1)
+	for i, record := range records[1:] {
+		opponent, found := rules.findMove(record[0])
+		if !found {
+			return nil, fmt.Errorf("line %d: no move %q", i+2, record[0])
+		}
+
+		var letter byte
+		if part == 1 {
+			you, found := rules.findMove(record[1])
+			if !found {
+				return nil, fmt.Errorf("line %d: no move %q", i+2, record[1])
+			}
+			letter = letterFor(rules.youLetters, you)
+		} else {
+			outcome, found := parseOutcome(record[1])
+			if !found {
+				return nil, fmt.Errorf("line %d: no outcome %q", i+2, record[1])
+			}
+			letter = letterFor(rules.outcomeLetters, outcome)
+		}
+		lines = append(lines, fmt.Sprintf("%c %c", letterFor(rules.opponentLetters, opponent), letter))
+	}
+	return lines, nil
+}
+
+// writeGuideCSV writes the guide read as moves for part 1, or as outcomes for
+// part 2.
+func writeGuideCSV(w io.Writer, rules *Rules, lines []string, part int) error {
+	out := csv.NewWriter(w)
+	out.Write(csvHeaders[part])
+	for _, line := range lines {
+		if part == 1 {
+			game := rules.parseGame1(line)
+			out.Write([]string{rules.name(game.opponent), rules.name(game.you)})
+		} else {
+			game := rules.parseGame2(line)
+			out.Write([]string{rules.name(game.opponent), game.outcome.String()})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func (this *Rules) findMove(name string) (Move, bool) {
+	for move, rule := range this.moves {
+		if rule.name == name {
+			return Move(move), true
+		}
+	}
+	return 0, false
+}
+
+func letterFor[T comparable](letters map[byte]T, value T) byte {
+	for letter, v := range letters {
+		if v == value {
+			return letter
+		}
+	}
+	panic(value)
+}
+
+// writeTables writes the rules as the move, outcome, round and response
+// tables. The response table is the move played for each outcome the guide
+// can ask for, so the SQL needn't choose between several.
+func (this *Rules) writeTables(move, outcome, round, response io.Writer) error {
+	moves := csv.NewWriter(move)
+	moves.Write([]string{"move", "score"})
+	for _, rule := range this.moves {
+		moves.Write([]string{rule.name, strconv.Itoa(rule.score)})
+	}
+	moves.Flush()
+
+	outcomes := csv.NewWriter(outcome)
+	outcomes.Write([]string{"outcome", "score"})
+	for o, score := range this.outcomeScores {
+		outcomes.Write([]string{Outcome(o).String(), strconv.Itoa(score)})
+	}
+	outcomes.Flush()
+
+	rounds := csv.NewWriter(round)
+	rounds.Write([]string{"you", "me", "outcome"})
+	for opponent := range this.moves {
+		for you := range this.moves {
+			rounds.Write([]string{this.name(Move(opponent)), this.name(Move(you)), this.result[you][opponent].String()})
+		}
+	}
+	rounds.Flush()
+
+	responses := csv.NewWriter(response)
+	responses.Write([]string{"you", "outcome", "me"})
+	for opponent := range this.moves {
+		for o := range outcomeNames {
+			reply := this.reply(Move(opponent), Outcome(o))
+			responses.Write([]string{this.name(Move(opponent)), Outcome(o).String(), this.name(reply)})
+		}
+	}
+	responses.Flush()
+
+	for _, w := range []*csv.Writer{moves, outcomes, rounds, responses} {
+		if err := w.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTables builds rules from the move, outcome and round tables. The
+// tables have no letters, so the moves are A, B, C... for the opponent and
+// end at Z for you, in the order of the move table, and the outcomes are X,
+// Y and Z. The response table must hold the reply the rules make to every
+// move for every outcome, and nothing else.
+func parseTables(move, outcome, round, response io.Reader) (*Rules, error) {
+	this := &Rules{
+		opponentLetters: make(map[byte]Move),
+		youLetters:      make(map[byte]Move),
+		outcomeLetters:  make(map[byte]Outcome),
+	}
+
+	moves, err := readTable(move, "move", "score")
+	if err != nil {
+		return nil, fmt.Errorf("move: %v", err)
+	}
+	if len(moves) == 0 || len(moves) > 26 {
+		return nil, fmt.Errorf("move: can't have %d moves", len(moves))
+	}
+	for i, record := range moves {
+		if _, found := this.findMove(record[0]); found {
+			return nil, fmt.Errorf("move: %s again", record[0])
+		}
+		score, err := strconv.Atoi(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("move: bad score %q", record[1])
+		}
+		this.moves = append(this.moves, MoveRule{record[0], score})
+		this.opponentLetters[byte('A'+i)] = Move(i)
+		this.youLetters[byte('Z'-len(moves)+1+i)] = Move(i)
+	}
+
+	outcomes, err := readTable(outcome, "outcome", "score")
+	if err != nil {
+		return nil, fmt.Errorf("outcome: %v", err)
+	}
+	seen := [3]bool{}
+	for _, record := range outcomes {
+		o, found := parseOutcome(record[0])
+		if !found {
+			return nil, fmt.Errorf("outcome: must be lose, draw or win, not %q", record[0])
+		}
+		if this.outcomeScores[o], err = strconv.Atoi(record[1]); err != nil {
+			return nil, fmt.Errorf("outcome: bad score %q", record[1])
+		}
+		seen[o] = true
+	}
+	for o := range seen {
+		if !seen[o] {
+			return nil, fmt.Errorf("outcome: no score for %s", Outcome(o))
+		}
+		this.outcomeLetters[byte('X'+o)] = Outcome(o)
+	}
+
+	rounds, err := readTable(round, "you", "me", "outcome")
+	if err != nil {
+		return nil, fmt.Errorf("round: %v", err)
+	}
+	this.result = newResults(len(this.moves))
+	for _, record := range rounds {
+		opponent, found := this.findMove(record[0])
+		if !found {
+			return nil, fmt.Errorf("round: no move %q", record[0])
+		}
+		you, found := this.findMove(record[1])
+		if !found {
+			return nil, fmt.Errorf("round: no move %q", record[1])
+		}
+		o, found := parseOutcome(record[2])
+		if !found {
+			return nil, fmt.Errorf("round: no outcome %q", record[2])
+		}
+		if err := this.setResult(you, opponent, o); err != nil {
+			return nil, fmt.Errorf("round: %v", err)
+		}
+	}
+	if err := this.check(); err != nil {
+		return nil, err
+	}
+
+	responses, err := readTable(response, "you", "outcome", "me")
+	if err != nil {
+		return nil, fmt.Errorf("response: %v", err)
+	}
+	if len(responses) != 3*len(this.moves) {
+		return nil, fmt.Errorf("response: %d replies for %d moves, want 3 each", len(responses), len(this.moves))
+	}
+	answered := make(map[Game2]bool)
+	for _, record := range responses {
+		opponent, found := this.findMove(record[0])
+		if !found {
+			return nil, fmt.Errorf("response: no move %q", record[0])
+		}
+		o, found := parseOutcome(record[1])
+		if !found {
+			return nil, fmt.Errorf("response: no outcome %q", record[1])
+		}
+		if answered[Game2{opponent, o}] {
+			return nil, fmt.Errorf("response: to %s against %s again", o, record[0])
+		}
+		answered[Game2{opponent, o}] = true
+		if want := this.name(this.reply(opponent, o)); record[2] != want {
+			return nil, fmt.Errorf("response: to %s against %s the rules play %s, not %s", o, record[0], want, record[2])
+		}
+	}
+	return this, nil
+}
+
+// readTable reads a CSV file with the given header.
+func readTable(r io.Reader, header ...string) ([][]string, error) {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = len(header)
+	records, err := in.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(header, ",") {
+		return nil, fmt.Errorf("header should be %s", strings.Join(header, ","))
+	}
+	return records[1:], nil
+}
+
+var tableNames = []string{"move.csv", "outcome.csv", "round.csv", "response.csv"}
+
+func readTables(dir string) (*Rules, error) {
+	readers := make([]io.Reader, len(tableNames))
+	for i, name := range tableNames {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		readers[i] = file
+	}
+
+	rules, err := parseTables(readers[0], readers[1], readers[2], readers[3])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", dir, err)
+	}
+	return rules, nil
+}
+
+// exportCSV writes part1.csv, part2.csv and the rule tables into dir.
+func exportCSV(dir string, rules *Rules, lines []string) error {
+	files := make(map[string]*os.File)
+	for _, name := range append([]string{"part1.csv", "part2.csv"}, tableNames...) {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		files[name] = file
+	}
+
+	if err := writeGuideCSV(files["part1.csv"], rules, lines, 1); err != nil {
+		return err
+	}
+	if err := writeGuideCSV(files["part2.csv"], rules, lines, 2); err != nil {
+		return err
+	}
+	if err := rules.writeTables(files["move.csv"], files["outcome.csv"], files["round.csv"], files["response.csv"]); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
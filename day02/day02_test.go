@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Every pairing of opponent and guide letter.
+var allRounds = []string{
+	"A X", "A Y", "A Z",
+	"B X", "B Y", "B Z",
+	"C X", "C Y", "C Z",
+}
+
+func mustParseRules(t *testing.T, text string) *Rules {
+	t.Helper()
+	rules, err := parseRules(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+func TestGuideCSVRoundTrip(t *testing.T) {
+	rules := mustParseRules(t, rockPaperScissors)
+
+	for _, part := range []int{1, 2} {
+		var buf bytes.Buffer
+		if err := writeGuideCSV(&buf, rules, allRounds, part); err != nil {
+			t.Fatal(err)
+		}
+		lines, err := readGuideCSV(bytes.NewReader(buf.Bytes()), rules)
+		if err != nil {
+			t.Fatalf("part %d: %v\n%s", part, err, buf.String())
+		}
+		if !reflect.DeepEqual(lines, allRounds) {
+			t.Errorf("part %d: read back %q, want %q", part, lines, allRounds)
+		}
+	}
+}
+
+// The CSV files in pg are the ones the SQL reads, so they must match what
+// the Go writes.
+func TestGuideCSVMatchesPg(t *testing.T) {
+	rules := mustParseRules(t, rockPaperScissors)
+	input, err := os.ReadFile("input.txt")
+	if err != nil {
+		t.Skip(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(input), "\n"), "\n")
+
+	for part, name := range map[int]string{1: "pg/part1.csv", 2: "pg/part2.csv"} {
+		want, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := writeGuideCSV(&buf, rules, lines, part); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != string(want) {
+			t.Errorf("part %d: CSV differs from %s", part, name)
+		}
+	}
+}
+
+func TestReadGuideCSVErrors(t *testing.T) {
+	rules := mustParseRules(t, rockPaperScissors)
+
+	tests := []struct {
+		csv, err string
+	}{
+		{"", "no header"},
+		{"them,me\nrock,rock\n", "header should be"},
+		{"you,me\nrock,lizard\n", `line 2: no move "lizard"`},
+		{"you,outcome\nrock,tie\n", `line 2: no outcome "tie"`},
+	}
+	for _, test := range tests {
+		_, err := readGuideCSV(strings.NewReader(test.csv), rules)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: error %v, want it to contain %q", test.csv, err, test.err)
+		}
+	}
+}
+
+// Where several moves give an outcome, the highest scoring is played, so the
+// order the moves are listed in makes no difference.
+func TestReply(t *testing.T) {
+	beats := `
+outcome lose 0 X
+outcome draw 3 Y
+outcome win  6 Z
+beats rock scissors
+beats rock lizard
+beats paper rock
+beats paper spock
+beats scissors paper
+beats scissors lizard
+beats lizard spock
+beats lizard paper
+beats spock scissors
+beats spock rock
+`
+	orders := []string{`
+move rock     1 A V
+move paper    3 C X
+move scissors 5 E Z
+move lizard   4 D Y
+move spock    2 B W
+`, `
+move spock    2 B W
+move lizard   4 D Y
+move scissors 5 E Z
+move paper    3 C X
+move rock     1 A V
+`}
+
+	tests := []struct {
+		opponent string
+		outcome  Outcome
+		you      string
+	}{
+		{"rock", Lose, "scissors"},
+		{"rock", Draw, "rock"},
+		{"rock", Win, "paper"},
+		{"scissors", Lose, "lizard"},
+		{"scissors", Win, "spock"},
+		{"lizard", Lose, "paper"},
+		{"lizard", Win, "scissors"},
+		{"spock", Lose, "scissors"},
+		{"spock", Win, "lizard"},
+	}
+	for _, order := range orders {
+		rules := mustParseRules(t, order+beats)
+		for _, test := range tests {
+			opponent, _ := rules.findMove(test.opponent)
+			if got := rules.name(rules.reply(opponent, test.outcome)); got != test.you {
+				t.Errorf("to %s against %s, played %s, want %s", test.outcome, test.opponent, got, test.you)
+			}
+		}
+	}
+
+	// The rules file, being cyclic, must agree.
+	rpsls, err := os.ReadFile("rpsls.rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := mustParseRules(t, string(rpsls))
+	for _, test := range tests {
+		opponent, _ := rules.findMove(test.opponent)
+		if got := rules.name(rules.reply(opponent, test.outcome)); got != test.you {
+			t.Errorf("rpsls.rules: to %s against %s, played %s, want %s", test.outcome, test.opponent, got, test.you)
+		}
+	}
+}
+
+// Writing the rules as tables and reading them back gives the same game,
+// round for round.
+func TestTablesRoundTrip(t *testing.T) {
+	rpsls, err := os.ReadFile("rpsls.rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, text := range map[string]string{"rock paper scissors": rockPaperScissors, "rpsls": string(rpsls)} {
+		rules := mustParseRules(t, text)
+
+		var move, outcome, round, response bytes.Buffer
+		if err := rules.writeTables(&move, &outcome, &round, &response); err != nil {
+			t.Fatal(err)
+		}
+		read, err := parseTables(&move, &outcome, &round, &response)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if !reflect.DeepEqual(read.moves, rules.moves) {
+			t.Errorf("%s: moves %v, want %v", name, read.moves, rules.moves)
+		}
+		if read.outcomeScores != rules.outcomeScores {
+			t.Errorf("%s: outcome scores %v, want %v", name, read.outcomeScores, rules.outcomeScores)
+		}
+		if !reflect.DeepEqual(read.result, rules.result) {
+			t.Errorf("%s: results %v, want %v", name, read.result, rules.result)
+		}
+	}
+}
+
+// The tables in pg are the rules of rock paper scissors, lettered as the
+// puzzle letters them.
+func TestPgTables(t *testing.T) {
+	tables, err := readTables("pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := mustParseRules(t, rockPaperScissors)
+
+	if got, want := part1(tables, allRounds), part1(rules, allRounds); got != want {
+		t.Errorf("part 1 with the tables = %d, want %d", got, want)
+	}
+	if got, want := part2(tables, allRounds), part2(rules, allRounds); got != want {
+		t.Errorf("part 2 with the tables = %d, want %d", got, want)
+	}
+}
+
+// exportTables writes the rules as pg/day02.sql reads them, keyed by table.
+func exportTables(t *testing.T, rules *Rules) map[string][][]string {
+	t.Helper()
+	var move, outcome, round, response bytes.Buffer
+	if err := rules.writeTables(&move, &outcome, &round, &response); err != nil {
+		t.Fatal(err)
+	}
+	tables := make(map[string][][]string)
+	for name, b := range map[string]*bytes.Buffer{"move": &move, "outcome": &outcome, "round": &round, "response": &response} {
+		records, err := csv.NewReader(b).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables[name] = records[1:]
+	}
+	return tables
+}
+
+// sqlPart2 totals part 2 as pg/day02.sql does, joining each round of the
+// guide with the response table on the opponent's move and the outcome.
+func sqlPart2(t *testing.T, tables map[string][][]string, guide [][]string) int {
+	t.Helper()
+	scores := func(table string) map[string]int {
+		scores := make(map[string]int)
+		for _, record := range tables[table] {
+			score, err := strconv.Atoi(record[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			scores[record[0]] = score
+		}
+		return scores
+	}
+	moveScores, outcomeScores := scores("move"), scores("outcome")
+
+	total := 0
+	for _, round := range guide {
+		matches := 0
+		for _, response := range tables["response"] {
+			if response[0] == round[0] && response[1] == round[1] {
+				total += moveScores[response[2]] + outcomeScores[round[1]]
+				matches++
+			}
+		}
+		if matches != 1 {
+			t.Errorf("%s to %s matches %d responses", round[0], round[1], matches)
+		}
+	}
+	return total
+}
+
+// The SQL's part 2 agrees with the Go's, even where several moves give an
+// outcome.
+func TestSQLPart2(t *testing.T) {
+	rpsls, err := os.ReadFile("rpsls.rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, text := range map[string]string{"rock paper scissors": rockPaperScissors, "rpsls": string(rpsls)} {
+		rules := mustParseRules(t, text)
+
+		// Every opponent's move with every outcome, twice over.
+		var lines []string
+		for opponent := range rules.moves {
+			for o := range outcomeNames {
+				line := fmt.Sprintf("%c %c", letterFor(rules.opponentLetters, Move(opponent)), letterFor(rules.outcomeLetters, Outcome(o)))
+				lines = append(lines, line, line)
+			}
+		}
+
+		var guide bytes.Buffer
+		if err := writeGuideCSV(&guide, rules, lines, 2); err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(&guide).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := sqlPart2(t, exportTables(t, rules), records[1:]), part2(rules, lines); got != want {
+			t.Errorf("%s: part 2 in SQL = %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestParseTablesResponseErrors(t *testing.T) {
+	rpsls, err := os.ReadFile("rpsls.rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := mustParseRules(t, string(rpsls))
+
+	tests := []struct {
+		name   string
+		change func(response string) string
+		err    string
+	}{
+		{"other reply", func(response string) string {
+			return strings.Replace(response, "rock,lose,scissors", "rock,lose,lizard", 1)
+		}, "to lose against rock the rules play scissors, not lizard"},
+		{"repeated", func(response string) string {
+			return strings.Replace(response, "rock,draw,rock", "rock,lose,scissors", 1)
+		}, "to lose against rock again"},
+		{"missing", func(response string) string {
+			return strings.Replace(response, "rock,draw,rock\n", "", 1)
+		}, "14 replies for 5 moves"},
+	}
+	for _, test := range tests {
+		var move, outcome, round, response bytes.Buffer
+		if err := rules.writeTables(&move, &outcome, &round, &response); err != nil {
+			t.Fatal(err)
+		}
+		changed := test.change(response.String())
+		if changed == response.String() {
+			t.Fatalf("%s: changed nothing in\n%s", test.name, changed)
+		}
+		_, err := parseTables(&move, &outcome, &round, strings.NewReader(changed))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %v, want it to contain %q", test.name, err, test.err)
+		}
+	}
+}
@@ -1,24 +1,21 @@
 begin;
 
+-- The rules are shared with the Go solution: go run .. -export . ../input.txt
+-- writes these CSV files from its rules, and -tables . reads them back.
+
 create table outcome (
     outcome text primary key,
     score int not null
 );
 
-insert into outcome (outcome, score) values
-    ( 'lose', 0 ),
-    ( 'draw', 3 ),
-    ( 'win',  6 );
+\copy outcome from 'outcome.csv' delimiter ',' csv header;
 
 create table move (
     move text primary key,
     score int not null
 );
 
-insert into move (move, score) values
-    ( 'rock',     1 ),
-    ( 'paper',    2 ),
-    ( 'scissors', 3 );
+\copy move from 'move.csv' delimiter ',' csv header;
 
 create table round (
     you     text not null references move(move),
@@ -26,16 +23,18 @@ create table round (
     outcome text not null references outcome(outcome)
 );
 
-insert into round (you, me, outcome) values
-  ( 'rock',     'rock',     'draw' ),
-  ( 'rock',     'paper',    'win'  ),
-  ( 'rock',     'scissors', 'lose' ),
-  ( 'paper',    'rock',     'lose' ),
-  ( 'paper',    'paper',    'draw' ),
-  ( 'paper',    'scissors', 'win'  ),
-  ( 'scissors', 'rock',     'win'  ),
-  ( 'scissors', 'paper',    'lose' ),
-  ( 'scissors', 'scissors', 'draw' );
+\copy round from 'round.csv' delimiter ',' csv header;
+
+-- The move to play for each outcome. Where several moves give it, the Go
+-- rules have already chosen, so each round of part 2 matches just one.
+create table response (
+    you     text not null references move(move),
+    outcome text not null references outcome(outcome),
+    me      text not null references move(move),
+    primary key (you, outcome)
+);
+
+\copy response from 'response.csv' delimiter ',' csv header;
 
 create table part1 (
     you     text not null references move(move),
@@ -62,7 +61,7 @@ create table part2 (
 with scores as (
   select m.score + o.score as score
     from part2 p
-    join round r using (you, outcome)
+    join response r using (you, outcome)
     join move m on r.me = m.move
     join outcome o using (outcome)
 ) select sum(score) from scores;
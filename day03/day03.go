@@ -2,77 +2,146 @@ package main
 
 import (
 	"advent-of-code/aoc"
+	"flag"
 	"fmt"
+	"log"
+	"os"
+	"strings"
 )
 
+// Alphabet is the items which can be packed, in priority order from 1.
+type Alphabet struct {
+	items    string
+	priority [256]int // 0 for anything not in the alphabet
+}
+
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+var groupSize = flag.Int("group", 3, "how many elves there are in each group for part 2")
+var alphabetFlag = flag.String("alphabet", letters, "the items, lowest priority first")
+var report = flag.Bool("report", false, "list the badge chosen for each group on stderr")
+
+var alphabet Alphabet
+
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	var err error
+	alphabet, err = NewAlphabet(*alphabetFlag)
+	aoc.CheckErr(err)
+	if *groupSize < 1 {
+		log.Fatalf("group size must be at least 1, not %d", *groupSize)
+	}
+
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
 }
 
 func part1(lines []string) int {
 	total := 0
-	for _, line := range lines {
-		total += getPriorities(line)
+	for i, line := range lines {
+		priority, err := getPriorities(line)
+		if err != nil {
+			log.Fatalf("line %d: %v", i+1, err)
+		}
+		total += priority
 	}
 	return total
 }
 
 func part2(lines []string) int {
+	if len(lines)%*groupSize != 0 {
+		log.Fatalf("%d rucksacks can't be split into groups of %d", len(lines), *groupSize)
+	}
+
 	total := 0
-	for i := 0; i < len(lines); i += 3 {
-		total += getCommon(lines[i : i+3])
+	for i := 0; i < len(lines); i += *groupSize {
+		priority, err := getCommon(lines[i : i+*groupSize])
+		if err != nil {
+			log.Fatalf("group at lines %d-%d: %v", i+1, i+*groupSize, err)
+		}
+		if *report {
+			fmt.Fprintf(os.Stderr, "group %d: %c (%d)\n", i / *groupSize + 1, alphabet.items[priority-1], priority)
+		}
+		total += priority
 	}
 	return total
 }
 
-func getPriorities(rucksack string) int {
-	seen := make([]int, 52)
-
-	half := len(rucksack) / 2
-	for i := 0; i < half; i++ {
-		item := rucksack[i]
-		value := getValue(item)
-		seen[value] = 1
+func NewAlphabet(items string) (Alphabet, error) {
+	this := Alphabet{items: items}
+	if items == "" {
+		return this, fmt.Errorf("empty alphabet")
 	}
-	for i := half; i < len(rucksack); i++ {
-		item := rucksack[i]
-		value := getValue(item)
-		if seen[value] > 0 {
-			return value + 1
+	for i := 0; i < len(items); i++ {
+		if this.priority[items[i]] != 0 {
+			return this, fmt.Errorf("%q appears twice in the alphabet", items[i])
 		}
+		this.priority[items[i]] = i + 1
 	}
-	return 0
+	return this, nil
 }
 
-func getCommon(lines []string) int {
-	seen := make([]int, 52)
+func getPriorities(rucksack string) (int, error) {
+	if len(rucksack)%2 != 0 {
+		return 0, fmt.Errorf("%d items can't be split into two compartments", len(rucksack))
+	}
 
-	all := 0
-	for i, line := range lines {
-		bit := 1 << i
-		all |= bit
+	half := len(rucksack) / 2
+	first, err := getItems(rucksack[:half])
+	if err != nil {
+		return 0, err
+	}
+	second, err := getItems(rucksack[half:])
+	if err != nil {
+		return 0, err
+	}
+	return onlyItem(first.Intersect(second), "in both compartments")
+}
 
-		for _, item := range line {
-			value := getValue(byte(item))
-			seen[value] |= bit
+func getCommon(lines []string) (int, error) {
+	common, err := getItems(lines[0])
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range lines[1:] {
+		items, err := getItems(line)
+		if err != nil {
+			return 0, err
 		}
+		common = common.Intersect(items)
 	}
+	return onlyItem(common, "common to the group")
+}
 
-	for i, mask := range seen {
-		if mask == all {
-			return i + 1
-		}
+// onlyItem checks there's exactly one item in the set, returning its
+// priority.
+func onlyItem(items aoc.BitSet, where string) (int, error) {
+	switch items.Count() {
+	case 1:
+		priority, _ := items.Next(0)
+		return priority, nil
+	case 0:
+		return 0, fmt.Errorf("no item is %s", where)
+	default:
+		names := make([]string, 0)
+		items.Each(func(priority int) {
+			names = append(names, string(alphabet.items[priority-1]))
+		})
+		return 0, fmt.Errorf("several items are %s: %s", where, strings.Join(names, ", "))
 	}
-	return 0
 }
 
-func getValue(item byte) int {
-	if item >= 'a' && item <= 'z' {
-		return int(item - 'a')
+// getItems returns the priorities of the items.
+func getItems(items string) (aoc.BitSet, error) {
+	var set aoc.BitSet
+	for i := 0; i < len(items); i++ {
+		priority := alphabet.priority[items[i]]
+		if priority == 0 {
+			return set, fmt.Errorf("unknown item %q", items[i])
+		}
+		set.Set(priority)
 	}
-	return int(item - 'A' + 26)
+	return set, nil
 }
@@ -2,20 +2,40 @@ package main
 
 import (
 	"advent-of-code/aoc"
+	"advent-of-code/aoc/interval"
+	"advent-of-code/aoc/mathx"
+	"flag"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 )
 
-type Assignment struct {
-	first, last int
+// Assignment is the sections an elf has to clean.
+type Assignment = interval.Interval
+
+// Elf is an assignment, and where it was in the input: the line number, and
+// 1 or 2 for which side of the comma.
+type Elf struct {
+	Assignment
+	line, side int
 }
 
+var sweep = flag.Bool("sweep", false, "instead of the puzzle, compare every assignment with every other")
+var listLimit = flag.Int("list", 20, "how many gaps and containments to list in sweep mode, or -1 for all")
+
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	if *sweep {
+		aoc.RefuseJSON("-sweep")
+		sweepAll(lines)
+		return
+	}
+
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
 }
 
 func part1(lines []string) int {
@@ -48,17 +68,151 @@ func parseLine(line string) (Assignment, Assignment) {
 func parseAssignment(line string) Assignment {
 	values := aoc.ParseInts(strings.Split(line, "-"))
 
-	return Assignment{values[0], values[1]}
+	return interval.New(values[0], values[1])
 }
 
 func hasFullOverlap(lhs, rhs Assignment) bool {
-	return ((lhs.first <= rhs.first) && (lhs.last >= rhs.last)) || ((rhs.first <= lhs.first) && (rhs.last >= lhs.last))
+	return lhs.ContainsInterval(rhs) || rhs.ContainsInterval(lhs)
 }
 
 func hasPartialOverlap(lhs, rhs Assignment) bool {
-	return !isDistinct(lhs, rhs)
+	return lhs.Overlaps(rhs)
+}
+
+func sweepAll(lines []string) {
+	elves := make([]Elf, 0, 2*len(lines))
+	for i, line := range lines {
+		lhs, rhs := parseLine(line)
+		elves = append(elves, Elf{lhs, i + 1, 1}, Elf{rhs, i + 1, 2})
+	}
+	if len(elves) == 0 {
+		return
+	}
+
+	assignments := make([]Assignment, len(elves))
+	bounds := elves[0].Assignment
+	for i, elf := range elves {
+		assignments[i] = elf.Assignment
+		bounds.Lo = mathx.Min(bounds.Lo, elf.Lo)
+		bounds.Hi = mathx.Max(bounds.Hi, elf.Hi)
+	}
+	uncovered := interval.NewSet(assignments...).Complement(bounds)
+
+	most, where := maxCoverage(elves)
+	fmt.Printf("%d assignments, covering sections %d-%d\n", len(elves), bounds.Lo, bounds.Hi)
+	fmt.Printf("most elves on one section: %d, first at sections %d-%d\n", most, where.Lo, where.Hi)
+	gaps := uncovered.Intervals()
+	fmt.Printf("sections nobody covers: %d, in %d stretches\n", uncovered.Len(), len(gaps))
+	for i, gap := range gaps {
+		if *listLimit >= 0 && i >= *listLimit {
+			break
+		}
+		fmt.Printf("  %d-%d\n", gap.Lo, gap.Hi)
+	}
+
+	listed := 0
+	count := containments(elves, func(outer, inner Elf) {
+		if *listLimit >= 0 && listed >= *listLimit {
+			return
+		}
+		relation := "contains"
+		if outer.Assignment == inner.Assignment {
+			relation = "is the same as"
+		}
+		fmt.Printf("  %v %s %v\n", outer, relation, inner)
+		listed++
+	})
+	fmt.Printf("containments between elves in different pairs: %d\n", count)
+}
+
+// maxCoverage sweeps along the sections, counting elves in as their
+// assignments start and out once they end. It returns the most at once, and
+// the first stretch where that happens.
+func maxCoverage(elves []Elf) (int, Assignment) {
+	type event struct {
+		section, change int
+	}
+	events := make([]event, 0, 2*len(elves))
+	for _, elf := range elves {
+		events = append(events, event{elf.Lo, +1}, event{elf.Hi + 1, -1})
+	}
+	// Leaving before arriving, so that assignments which only touch don't
+	// count as overlapping.
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].section != events[j].section {
+			return events[i].section < events[j].section
+		}
+		return events[i].change < events[j].change
+	})
+
+	most, where := 0, interval.Empty
+	count := 0
+	for i, e := range events {
+		count += e.change
+		if count > most {
+			most = count
+			where = interval.New(e.section, events[i+1].section-1)
+		}
+	}
+	return most, where
+}
+
+// containments calls found for every pair of elves from different lines
+// where one's assignment contains the other's, returning how many there are.
+// Sorting by start, then longest first, means anything containing an
+// assignment comes before it. A tree of the furthest end in each range of
+// the sorted elves finds those quickly, so the time taken depends on the
+// number of elves and containments, not the number of pairs.
+func containments(elves []Elf, found func(outer, inner Elf)) int {
+	sorted := append([]Elf{}, elves...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Lo != sorted[j].Lo {
+			return sorted[i].Lo < sorted[j].Lo
+		}
+		return sorted[i].Hi > sorted[j].Hi
+	})
+
+	// furthest[node] is the greatest Hi under that node of a segment tree
+	// over sorted, with the leaves at size+i.
+	size := 1
+	for size < len(sorted) {
+		size *= 2
+	}
+	furthest := make([]int, 2*size)
+	for i := range furthest {
+		furthest[i] = math.MinInt
+	}
+	for i, elf := range sorted {
+		furthest[size+i] = elf.Hi
+	}
+	for node := size - 1; node >= 1; node-- {
+		furthest[node] = mathx.Max(furthest[2*node], furthest[2*node+1])
+	}
+
+	count := 0
+	var visit func(node, lo, hi, before int, inner Elf)
+	visit = func(node, lo, hi, before int, inner Elf) {
+		if lo >= before || furthest[node] < inner.Hi {
+			return
+		}
+		if node >= size {
+			outer := sorted[lo]
+			if outer.line != inner.line {
+				count++
+				found(outer, inner)
+			}
+			return
+		}
+		mid := (lo + hi) / 2
+		visit(2*node, lo, mid, before, inner)
+		visit(2*node+1, mid, hi, before, inner)
+	}
+	for i, inner := range sorted {
+		visit(1, 0, size, i, inner)
+	}
+	return count
 }
 
-func isDistinct(lhs, rhs Assignment) bool {
-	return (lhs.last < rhs.first) || (rhs.last < lhs.first)
+func (this Elf) String() string {
+	return fmt.Sprintf("elf %d.%d (%d-%d)", this.line, this.side, this.Lo, this.Hi)
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"advent-of-code/aoc"
+	"advent-of-code/aoc/mathx"
+	"flag"
 	"fmt"
 	"strings"
 )
@@ -13,40 +15,68 @@ type Move struct {
 	from, to, howMany int
 }
 
-const debug = !true
+// Crane rearranges the stacks as a move says.
+type Crane interface {
+	Apply(stacks []Stack, move Move) []Stack
+}
 
-func main() {
-	filename := aoc.GetFilename()
-	input := aoc.Slurp(filename)
+type CrateMover9000 struct{}
+type CrateMover9001 struct{}
 
-	fmt.Println(part1(input))
-	fmt.Println(part2(input))
+// CappedCrane can lift several crates at once, but no more than its
+// capacity, so bigger moves are split.
+type CappedCrane struct {
+	capacity int
 }
 
-func part1(input string) string {
-	stacks, moves := parseInput(input)
+var renderInput = flag.Bool("render", false, "instead of the puzzle, write the input back out as it was read")
+var capacity = flag.Int("capacity", 0, "instead of the puzzle, use a crane lifting at most this many crates at once")
 
-	printStacks(stacks)
+const debug = !true
 
-	for _, move := range moves {
-		stacks = applyMove9000(stacks, move)
-		printStacks(stacks)
+// The drawing is padded with trailing space, which is kept so it can be
+// rendered back exactly, though parsing doesn't rely on it.
+const inputRules = aoc.Normalise&^aoc.TrimTrailingSpace | aoc.EnsureFinalNewline
+
+func main() {
+	filename := aoc.GetFilename()
+	input, changes := aoc.SlurpNormalised(filename, inputRules)
+	aoc.ReportInputChanges(filename, changes)
+
+	if *renderInput {
+		aoc.RefuseJSON("-render")
+		fmt.Print(render(parseInput(input)))
+		return
 	}
 
-	ret := ""
-	for _, stack := range stacks {
-		ret = fmt.Sprintf("%s%c", ret, stack[len(stack)-1])
+	if *capacity > 0 {
+		aoc.RefuseJSON("-capacity")
+		fmt.Println(solve(input, CappedCrane{*capacity}))
+		return
 	}
-	return ret
+
+	aoc.PrintAnswer(1, part1(input))
+	aoc.PrintAnswer(2, part2(input))
+}
+
+func part1(input string) string {
+	return solve(input, CrateMover9000{})
 }
 
 func part2(input string) string {
+	return solve(input, CrateMover9001{})
+}
+
+func solve(input string, crane Crane) string {
 	stacks, moves := parseInput(input)
 
 	printStacks(stacks)
 
 	for _, move := range moves {
-		stacks = applyMove9001(stacks, move)
+		if debug {
+			fmt.Println(move)
+		}
+		stacks = crane.Apply(stacks, move)
 		printStacks(stacks)
 	}
 
@@ -57,36 +87,36 @@ func part2(input string) string {
 	return ret
 }
 
-func applyMove9000(stacks []Stack, move Move) []Stack {
-	if debug {
-		fmt.Printf("Move %d from %d to %d\n", move.howMany, move.from, move.to)
-	}
-	from := stacks[move.from-1]
-	to := stacks[move.to-1]
-
+// Apply moves the crates one at a time, reversing their order.
+func (this CrateMover9000) Apply(stacks []Stack, move Move) []Stack {
 	for i := 0; i < move.howMany; i++ {
-		to = append(to, from[len(from)-i-1])
+		stacks = moveBlock(stacks, move.from, move.to, 1)
 	}
-
-	stacks[move.to-1] = to
-	stacks[move.from-1] = from[0 : len(from)-move.howMany]
-
 	return stacks
 }
 
-func applyMove9001(stacks []Stack, move Move) []Stack {
-	if debug {
-		fmt.Printf("Move %d from %d to %d\n", move.howMany, move.from, move.to)
-	}
-	from := stacks[move.from-1]
-	to := stacks[move.to-1]
+// Apply moves the crates all at once, keeping their order.
+func (this CrateMover9001) Apply(stacks []Stack, move Move) []Stack {
+	return moveBlock(stacks, move.from, move.to, move.howMany)
+}
 
-	for i := 0; i < move.howMany; i++ {
-		to = append(to, from[len(from)-move.howMany+i])
+// Apply moves the crates in blocks of up to the capacity, starting from the
+// top.
+func (this CappedCrane) Apply(stacks []Stack, move Move) []Stack {
+	for left := move.howMany; left > 0; left -= this.capacity {
+		stacks = moveBlock(stacks, move.from, move.to, mathx.Min(left, this.capacity))
 	}
+	return stacks
+}
 
-	stacks[move.to-1] = to
-	stacks[move.from-1] = from[0 : len(from)-move.howMany]
+// moveBlock lifts the top n crates of one stack onto another, keeping their
+// order. Stacks are numbered from 1.
+func moveBlock(stacks []Stack, from, to, n int) []Stack {
+	source := stacks[from-1]
+	block := source[len(source)-n:]
+
+	stacks[to-1] = append(stacks[to-1], block...)
+	stacks[from-1] = source[:len(source)-n]
 
 	return stacks
 }
@@ -100,8 +130,7 @@ func parseInput(input string) ([]Stack, []Move) {
 func parseStacks(input string) []Stack {
 	lines := strings.Split(input, "\n")
 
-	counts := lines[len(lines)-1]
-	count := (len(counts) + 2) / 4
+	count := len(strings.Fields(lines[len(lines)-1]))
 
 	stacks := make([]Stack, count)
 
@@ -109,7 +138,8 @@ func parseStacks(input string) []Stack {
 		line := lines[i]
 		j := 1
 		for s := range stacks {
-			if line[j] != ' ' {
+			// Trailing space may have been trimmed from the drawing
+			if j < len(line) && line[j] != ' ' {
 				stacks[s] = append(stacks[s], Crate(line[j]))
 			}
 			j += 4
@@ -124,14 +154,67 @@ func printStacks(stacks []Stack) {
 		return
 	}
 
-	for i, stack := range stacks {
-		fmt.Printf("[%d]", i+1)
-		for _, crate := range stack {
-			fmt.Printf(" %c", crate)
+	fmt.Println(renderStacks(stacks))
+}
+
+// render writes stacks and moves back out as they appear in the puzzle input,
+// byte for byte.
+func render(stacks []Stack, moves []Move) string {
+	var b strings.Builder
+	b.WriteString(renderStacks(stacks))
+	b.WriteString("\n")
+	b.WriteString(renderMoves(moves))
+	return b.String()
+}
+
+// renderStacks draws the stacks with the tops of the tallest at the top, and
+// their numbers along the bottom. Each stack takes four columns, with its
+// number under its crates, and every row is padded to the same width as the
+// puzzle pads it.
+func renderStacks(stacks []Stack) string {
+	height := 0
+	for _, stack := range stacks {
+		if len(stack) > height {
+			height = len(stack)
+		}
+	}
+
+	var b strings.Builder
+	for row := height - 1; row >= 0; row-- {
+		for i, stack := range stacks {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			if row < len(stack) {
+				fmt.Fprintf(&b, "[%c]", stack[row])
+			} else {
+				b.WriteString("   ")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	for i := range stacks {
+		if i > 0 {
+			b.WriteString(" ")
 		}
-		fmt.Println("")
+		fmt.Fprintf(&b, " %-2d", i+1)
 	}
-	fmt.Println()
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+func renderMoves(moves []Move) string {
+	var b strings.Builder
+	for _, move := range moves {
+		b.WriteString(move.String() + "\n")
+	}
+	return b.String()
+}
+
+func (this Move) String() string {
+	return fmt.Sprintf("move %d from %d to %d", this.howMany, this.from, this.to)
 }
 
 func parseMoves(input string) []Move {
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"advent-of-code/aoc"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Parsing a file and rendering it again gives back the file exactly.
+func TestRenderRoundTrip(t *testing.T) {
+	for _, filename := range []string{"example01.txt", "input.txt"} {
+		original, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input, _ := aoc.NormaliseInput(string(original), inputRules)
+
+		if got := render(parseInput(input)); got != string(original) {
+			t.Errorf("%s rendered as\n%s\nwant\n%s", filename, got, original)
+		}
+	}
+}
+
+// Past nine stacks the numbers take two digits, and still line up with the
+// crates above them.
+func TestRenderManyStacks(t *testing.T) {
+	stacks := make([]Stack, 11)
+	for i := range stacks {
+		stacks[i] = Stack{Crate('A' + i)}
+	}
+	stacks[10] = append(stacks[10], 'Z')
+	moves := []Move{{howMany: 1, from: 11, to: 10}}
+
+	want := "" +
+		strings.Repeat("    ", 10) + "[Z]\n" +
+		"[A] [B] [C] [D] [E] [F] [G] [H] [I] [J] [K]\n" +
+		" 1   2   3   4   5   6   7   8   9   10  11\n" +
+		"\n" +
+		"move 1 from 11 to 10\n"
+
+	got := render(stacks, moves)
+	if got != want {
+		t.Fatalf("rendered as\n%s\nwant\n%s", got, want)
+	}
+
+	stacks, moves = parseInput(got)
+	if len(stacks) != 11 || !reflect.DeepEqual(stacks[10], Stack{'K', 'Z'}) || len(moves) != 1 || moves[0] != (Move{howMany: 1, from: 11, to: 10}) {
+		t.Errorf("parsed back as %q, %v", stacks, moves)
+	}
+}
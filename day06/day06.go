@@ -2,15 +2,17 @@ package main
 
 import (
 	"advent-of-code/aoc"
-	"fmt"
 )
 
+const inputRules = aoc.Normalise | aoc.StripFinalNewline
+
 func main() {
 	filename := aoc.GetFilename()
-	input := aoc.Slurp(filename)
+	input, changes := aoc.SlurpNormalised(filename, inputRules)
+	aoc.ReportInputChanges(filename, changes)
 
-	fmt.Println(part1(input))
-	fmt.Println(part2(input))
+	aoc.PrintAnswer(1, part1(input))
+	aoc.PrintAnswer(2, part2(input))
 }
 
 func part1(input string) int {
@@ -35,15 +37,15 @@ func findMarker(input string, markerLen int) int {
 }
 
 func isMarker(candidate string) bool {
-	mask := uint32(0)
+	var seen aoc.BitSet
 
-	for _, char := range candidate {
-		bit := uint32(1) << (char - 'a')
+	for i := 0; i < len(candidate); i++ {
+		char := int(candidate[i])
 
-		if mask|bit == mask {
+		if seen.Has(char) {
 			return false
 		}
-		mask |= bit
+		seen.Set(char)
 	}
 	return true
 }
@@ -2,7 +2,6 @@ package main
 
 import (
 	"advent-of-code/aoc"
-	"fmt"
 )
 
 type Grid[T any] struct {
@@ -14,8 +13,8 @@ func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
 }
 
 func part1(lines []string) int {
@@ -38,16 +37,24 @@ func part1(lines []string) int {
 func part2(lines []string) int {
 	treeSize := ParseTrees(lines)
 
-	best := -1
-	for y := 0; y < treeSize.h; y++ {
+	rowBest := func(y int) int {
+		best := -1
 		for x := 0; x < treeSize.w; x++ {
 			score := computeScenicScore(treeSize, x, y)
 			if score > best {
 				best = score
 			}
 		}
+		return best
+	}
+	max := func(a, b int) int {
+		if b > a {
+			return b
+		}
+		return a
 	}
-	return best
+
+	return aoc.ParallelReduce(treeSize.h, 0, rowBest, -1, max)
 }
 
 func computeScenicScore(treeSize Grid[int], x, y int) int {
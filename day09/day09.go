@@ -2,7 +2,7 @@ package main
 
 import (
 	"advent-of-code/aoc"
-	"fmt"
+	"advent-of-code/aoc/sim"
 	"strings"
 )
 
@@ -10,12 +10,20 @@ type Vec2 struct {
 	x, y int
 }
 
+// Rope is the knots following the head as it makes its moves, one square
+// per step.
+type Rope struct {
+	knots   []Vec2
+	moves   []Vec2
+	visited map[Vec2]bool // by the tail
+}
+
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
 }
 
 func part1(lines []string) int {
@@ -27,28 +35,52 @@ func part2(lines []string) int {
 }
 
 func run(lines []string, knots int) int {
-	knot := make([]Vec2, knots)
+	rope := newRope(knots, parseMoves(lines))
+	sim.RunUntilStable(rope, 0)
+	return len(rope.visited)
+}
 
-	tailLog := make(map[Vec2]bool)
+func newRope(knots int, moves []Vec2) *Rope {
+	rope := &Rope{make([]Vec2, knots), moves, make(map[Vec2]bool)}
+	rope.visited[rope.tail()] = true
+	return rope
+}
 
-	tailLog[knot[knots-1]] = true
+// Step moves the head one square, reporting whether there was a move left
+// to make.
+func (this *Rope) Step() bool {
+	if len(this.moves) == 0 {
+		return false
+	}
+	knot := this.knots
+	knot[0] = knot[0].Add(this.moves[0])
+	this.moves = this.moves[1:]
+
+	for j := 0; j < len(knot)-1; j++ {
+		diff := knot[j].Sub(knot[j+1])
+		move := diff.Sign()
+		if diff != move {
+			knot[j+1] = knot[j+1].Add(move)
+		}
+	}
+	this.visited[this.tail()] = true
+	return true
+}
 
+func (this *Rope) tail() Vec2 {
+	return this.knots[len(this.knots)-1]
+}
+
+// parseMoves splits each line into moves of one square.
+func parseMoves(lines []string) []Vec2 {
+	moves := make([]Vec2, 0)
 	for _, line := range lines {
 		dir, dist := parseLine(line)
-
 		for i := 0; i < dist; i++ {
-			knot[0] = knot[0].Add(dir)
-			for j := 0; j < knots-1; j++ {
-				diff := knot[j].Sub(knot[j+1])
-				move := diff.Sign()
-				if diff != move {
-					knot[j+1] = knot[j+1].Add(move)
-				}
-			}
-			tailLog[knot[knots-1]] = true
+			moves = append(moves, dir)
 		}
 	}
-	return len(tailLog)
+	return moves
 }
 
 func parseLine(line string) (Vec2, int) {
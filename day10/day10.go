@@ -2,7 +2,6 @@ package main
 
 import (
 	"advent-of-code/aoc"
-	"fmt"
 	"strings"
 )
 
@@ -16,8 +15,8 @@ func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
-	part2(lines)
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
 }
 
 func part1(lines []string) int {
@@ -48,8 +47,10 @@ func part1(lines []string) int {
 	return signalStrength
 }
 
-func part2(lines []string) {
+func part2(lines []string) aoc.Answer {
 	vm := NewVM()
+	rows := []string{}
+	row := ""
 
 	for _, line := range lines {
 		incr := 0
@@ -63,18 +64,23 @@ func part2(lines []string) {
 
 		for i := 0; i < cycles; i++ {
 			if Abs(vm.x-vm.cycle) <= 1 {
-				fmt.Print("#")
+				row += "#"
 			} else {
-				fmt.Print(".")
+				row += "."
 			}
 			vm.cycle++
 			if vm.cycle == 40 {
-				fmt.Println()
+				rows = append(rows, row)
+				row = ""
 				vm.cycle = 0
 			}
 		}
 		vm.x += incr
 	}
+	if row != "" {
+		rows = append(rows, row)
+	}
+	return aoc.ImageAnswer(rows)
 }
 
 func NewVM(signalCycles ...int) VM {
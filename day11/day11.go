@@ -2,6 +2,8 @@ package main
 
 import (
 	"advent-of-code/aoc"
+	"advent-of-code/aoc/mathx"
+	"advent-of-code/aoc/sim"
 	"fmt"
 	"sort"
 	"strings"
@@ -9,6 +11,9 @@ import (
 
 type Op func(int) int
 
+// Troop is the simulation of the monkeys, one round per step.
+type Troop []Monkey
+
 type Monkey struct {
 	items                   []int
 	op                      Op
@@ -22,12 +27,12 @@ type Monkey struct {
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
 }
 
 func part1(lines []string) int {
-	monkeys := make([]Monkey, 0)
+	monkeys := make(Troop, 0)
 
 	for i := 0; i < len(lines); i += 7 {
 		monkey := parseMonkey(lines[i:])
@@ -35,9 +40,7 @@ func part1(lines []string) int {
 		monkeys = append(monkeys, monkey)
 	}
 
-	for i := 0; i < 20; i++ {
-		doRound(monkeys)
-	}
+	sim.Run(monkeys, 20)
 
 	inspections := make([]int, len(monkeys))
 	for i := range monkeys {
@@ -49,24 +52,25 @@ func part1(lines []string) int {
 }
 
 func part2(lines []string) int {
-	monkeys := make([]Monkey, 0)
+	monkeys := make(Troop, 0)
 
 	for i := 0; i < len(lines); i += 7 {
 		monkey := parseMonkey(lines[i:])
 		monkeys = append(monkeys, monkey)
 	}
 
-	totalMod := 1
+	// Worry levels only matter modulo each monkey's test, so they can be
+	// kept modulo the lcm of all of them.
+	tests := make([]int, len(monkeys))
 	for i := range monkeys {
-		totalMod *= monkeys[i].test
+		tests[i] = monkeys[i].test
 	}
+	totalMod := mathx.LcmAll(tests...)
 	for i := range monkeys {
 		monkeys[i].mod = totalMod
 	}
 
-	for i := 0; i < 10000; i++ {
-		doRound(monkeys)
-	}
+	sim.Run(monkeys, 10000)
 
 	inspections := make([]int, len(monkeys))
 	for i := range monkeys {
@@ -77,6 +81,34 @@ func part2(lines []string) int {
 	return inspections[0] * inspections[1]
 }
 
+func (this Troop) Step() bool {
+	doRound(this)
+	return true
+}
+
+// Fingerprint is the items each monkey holds. Inspection counts only grow,
+// so they are left out.
+func (this Troop) Fingerprint() string {
+	items := make([][]int, len(this))
+	for i := range this {
+		items[i] = this[i].items
+	}
+	return fmt.Sprint(items)
+}
+
+func (this Troop) Snapshot() Troop {
+	snapshot := make(Troop, len(this))
+	copy(snapshot, this)
+	for i := range snapshot {
+		snapshot[i].items = append([]int(nil), this[i].items...)
+	}
+	return snapshot
+}
+
+func (this Troop) Restore(snapshot Troop) {
+	copy(this, snapshot.Snapshot())
+}
+
 func doRound(monkeys []Monkey) {
 	for i := range monkeys {
 		for _, item := range monkeys[i].items {
@@ -89,7 +121,10 @@ func doRound(monkeys []Monkey) {
 }
 
 func (this Monkey) doItem(item int) (int, int) {
-	item = this.op(item%this.mod) / this.div
+	if this.mod != 0 {
+		item %= this.mod
+	}
+	item = this.op(item) / this.div
 
 	if int(item)%this.test == 0 {
 		return item, this.trueMonkey
@@ -101,7 +136,7 @@ func parseMonkey(lines []string) Monkey {
 	return Monkey{
 		items:       parseItems(lines[1]),
 		op:          parseOp(lines[2]),
-		mod:         10000000000,
+		mod:         0, // no reduction
 		div:         1,
 		test:        parseInt(lines[3], "  Test: divisible by "),
 		trueMonkey:  parseInt(lines[4], "    If true: throw to monkey "),
@@ -122,15 +157,15 @@ func parseOp(line string) Op {
 	line = line[len(prefix):]
 
 	if line == "* old" {
-		return func(x int) int { return x * x }
+		return func(x int) int { return mathx.MustMul(x, x) }
 	}
 	tokens := strings.Split(line, " ")
 	arg := aoc.ParseInt(tokens[1])
 
 	if tokens[0] == "+" {
-		return func(x int) int { return x + arg }
+		return func(x int) int { return mathx.MustAdd(x, arg) }
 	}
-	return func(x int) int { return x * arg }
+	return func(x int) int { return mathx.MustMul(x, arg) }
 }
 
 func parseInt(line string, prefix string) int {
@@ -2,7 +2,6 @@ package main
 
 import (
 	"advent-of-code/aoc"
-	"fmt"
 )
 
 type Vec2 struct {
@@ -21,8 +20,8 @@ type Solution struct {
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
 }
 
 func part1(lines []string) int {
@@ -2,7 +2,9 @@ package main
 
 import (
 	"advent-of-code/aoc"
+	"advent-of-code/aoc/parse"
 	"fmt"
+	"log"
 	"sort"
 )
 
@@ -25,17 +27,29 @@ type Entry struct {
 	packet *PacketValue
 }
 
+const inputRules = aoc.Normalise
+
 func main() {
 	filename := aoc.GetFilename()
-	lines := aoc.GetInputLines(filename)
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	lines, changes := aoc.GetNormalisedLines(filename, inputRules)
+	aoc.ReportInputChanges(filename, changes)
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
 }
 
 func part1(lines []string) int {
 	pair := 1
 	result := 0
-	for {
+	for len(lines) > 0 {
+		// Pairs are separated by any number of blank lines
+		if lines[0] == "" {
+			lines = lines[1:]
+			continue
+		}
+		if len(lines) < 2 || lines[1] == "" {
+			log.Fatalf("pair %d: missing right packet", pair)
+		}
+
 		left := parseLine(lines[0])
 		right := parseLine(lines[1])
 		comp := compare(*left, *right)
@@ -45,10 +59,7 @@ func part1(lines []string) int {
 			result += pair
 		}
 
-		if len(lines) == 2 {
-			break
-		}
-		lines = lines[3:]
+		lines = lines[2:]
 		pair++
 	}
 	return result
@@ -80,51 +91,29 @@ func part2(lines []string) int {
 	return ret
 }
 
-func parseLine(line string) *PacketValue {
-	rest, value := parse(line)
-	if len(rest) != 0 {
-		panic("Parse error")
-	}
-	return value
-}
-
-func parse(line string) (string, *PacketValue) {
-	//fmt.Printf("parse('%s')\n", line)
-	if line[0] == '[' {
-		rest, listValue := parseListValue(line[1:])
-		return rest, &PacketValue{
-			value: listValue,
-		}
-	}
-	rest, intValue := parseIntValue(line)
-	return rest, &PacketValue{
-		value: intValue,
-	}
-}
-
-func parseListValue(line string) (string, []*PacketValue) {
-	//fmt.Printf("parseList('%s')\n", line)
-	values := make([]*PacketValue, 0)
-	for line[0] != ']' {
-		next, value := parse(line)
-		values = append(values, value)
-		if next[0] == ',' {
-			next = next[1:]
-		}
-		line = next
-	}
-	return line[1:], values
+var packetParser parse.Parser[*PacketValue]
+
+// packet = int | "[" [ packet ( "," packet )* ] "]"
+func init() {
+	packetParser = parse.Choice(
+		parse.Map(parse.Int(), func(value int) *PacketValue {
+			return &PacketValue{value}
+		}),
+		parse.Map(
+			parse.ListOf("[", parse.Lazy(func() parse.Parser[*PacketValue] { return packetParser }), parse.Literal(","), "]"),
+			func(values []*PacketValue) *PacketValue {
+				return &PacketValue{values}
+			},
+		),
+	)
 }
 
-func parseIntValue(line string) (string, int) {
-	//fmt.Printf("parseInt('%s') -> ", line)
-	value := 0
-	for line[0] >= '0' && line[0] <= '9' {
-		value = value*10 + int(line[0]-'0')
-		line = line[1:]
+func parseLine(line string) *PacketValue {
+	value, err := parse.Parse(packetParser, line)
+	if err != nil {
+		log.Fatalf("%q: %v", line, err)
 	}
-	//fmt.Printf("['%s', %d]\n", line, value)
-	return line, value
+	return value
 }
 
 func (this PacketValue) String() string {
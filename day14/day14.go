@@ -2,16 +2,28 @@ package main
 
 import (
 	"advent-of-code/aoc"
+	"advent-of-code/aoc/explore"
+	"advent-of-code/aoc/sim"
+	"flag"
 	"fmt"
+	"os"
 	"strings"
 )
 
+var interactive = flag.Bool("explore", false, "step through part 1 interactively")
+
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	if *interactive {
+		aoc.RefuseJSON("-explore")
+		explorePart1(lines)
+		return
+	}
+
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
 }
 
 type Material rune
@@ -29,19 +41,77 @@ type Vec2 struct {
 	x, y int
 }
 
+// Pouring is the simulation of sand falling into the cave, one unit of sand
+// per step.
+type Pouring struct {
+	cave   *aoc.InfiniteGrid[Material]
+	start  Vec2
+	placed []Vec2
+}
+
 func part1(lines []string) int {
-	cave := parseCave(lines)
+	pouring := newPouring(parseCave(lines), Vec2{500, 0})
+	//pouring.cave.Print("%c", 0)
 
-	start := Vec2{500, 0}
+	sim.RunUntilStable(pouring, 0)
+	//pouring.cave.Print("%c", 0)
+	return len(pouring.placed) // booyakasha
+}
+
+func explorePart1(lines []string) {
+	pouring := newPouring(parseCave(lines), Vec2{500, 0})
+
+	explorer := explore.New[int](pouring)
+	explorer.AddCondition("below", "Y: until sand comes to rest at or below Y", func(args []int) bool {
+		return len(args) > 0 && len(pouring.placed) > 0 && pouring.placed[len(pouring.placed)-1].y >= args[0]
+	})
+	aoc.CheckErr(explorer.Run(os.Stdin, os.Stdout))
+}
+
+func newPouring(cave *aoc.InfiniteGrid[Material], start Vec2) *Pouring {
 	cave.Set(start.x, start.y, Source)
-	//cave.Print("%c", 0)
-	sands := 0 // booyakasha
-	for emitSand(cave, start) {
-		sands++
-		//cave.Print("%c", 0)
+	return &Pouring{cave, start, nil}
+}
+
+// Step emits a unit of sand, reporting whether it came to rest.
+func (this *Pouring) Step() bool {
+	pos, rested := emitSand(this.cave, this.start)
+	if rested {
+		this.placed = append(this.placed, pos)
+	}
+	return rested
+}
+
+// Sand only ever accumulates, so the amount placed identifies the state.
+func (this *Pouring) Fingerprint() int {
+	return len(this.placed)
+}
+
+func (this *Pouring) Bounds() (minX, minY, maxX, maxY int) {
+	return this.cave.Bounds()
+}
+
+func (this *Pouring) Cell(x, y int) rune {
+	return rune(this.cave.Get(x, y))
+}
+
+func (this *Pouring) Status() string {
+	return fmt.Sprintf("sand %d", len(this.placed))
+}
+
+func (this *Pouring) Snapshot() int {
+	return len(this.placed)
+}
+
+// Restore removes sand placed since the snapshot, or pours more to catch up.
+func (this *Pouring) Restore(placed int) {
+	for len(this.placed) > placed {
+		pos := this.placed[len(this.placed)-1]
+		this.cave.Set(pos.x, pos.y, Air)
+		this.placed = this.placed[:len(this.placed)-1]
+	}
+	for len(this.placed) < placed && this.Step() {
 	}
-	//cave.Print("%c", 0)
-	return sands
 }
 
 func part2(lines []string) int {
@@ -70,20 +140,20 @@ func part2(lines []string) int {
 	return sands
 }
 
-func emitSand(cave *aoc.InfiniteGrid[Material], start Vec2) bool {
+func emitSand(cave *aoc.InfiniteGrid[Material], start Vec2) (Vec2, bool) {
 	pos := start
 	for cave.OnGrid(pos.x, pos.y) {
 		nextPos := moveSand(*cave, pos)
 		if nextPos == pos {
 			if cave.Get(pos.x, pos.y) == Sand {
-				return false
+				return pos, false
 			}
 			cave.Set(pos.x, pos.y, Sand)
-			return true
+			return pos, true
 		}
 		pos = nextPos
 	}
-	return false
+	return pos, false
 }
 
 func moveSand(cave aoc.InfiniteGrid[Material], from Vec2) Vec2 {
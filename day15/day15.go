@@ -2,16 +2,23 @@ package main
 
 import (
 	"advent-of-code/aoc"
-	"fmt"
+	"advent-of-code/aoc/checkpoint"
+	"advent-of-code/aoc/interval"
+	"flag"
 	"regexp"
 )
 
+var checkpointPrefix = flag.String("checkpoint", "", "save progress to, and resume from, files with this prefix")
+var checkpointInterval = flag.Duration("checkpoint-interval", checkpoint.DefaultInterval, "how often to save progress")
+
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	aoc.PrintAnswer(1, part1(lines))
+
+	id := checkpoint.Identify(lines, "part2")
+	aoc.PrintAnswer(2, part2(lines, checkpoint.New(*checkpointPrefix, "part2", id, *checkpointInterval)))
 }
 
 type Vec2 struct {
@@ -23,8 +30,12 @@ type Pair struct {
 	distance       int
 }
 
-type Segment struct {
-	start, length int
+// How many rows are scanned between checkpoints.
+const rowBlock = 100_000
+
+// Saved is the next row to scan.
+type Saved struct {
+	Y int
 }
 
 func part1(lines []string) int {
@@ -45,15 +56,10 @@ func part1(lines []string) int {
 		}
 	}
 
-	total := -len(beacons)
-	for _, segment := range segments {
-		total += segment.length
-	}
-
-	return total
+	return segments.Len() - len(beacons)
 }
 
-func part2(lines []string) int {
+func part2(lines []string, cp *checkpoint.Checkpointer) int {
 	pairs := make([]*Pair, len(lines))
 
 	for i, line := range lines {
@@ -64,29 +70,45 @@ func part2(lines []string) int {
 	//maxY := 20
 	maxY := 4_000_000
 
-	for y := maxY; y >= 0; y-- {
-		segments := GetSegments(pairs, y)
-		if len(segments) > 1 {
-			x := segments[1].start - 1
-			return y + x*4_000_000
+	saved := Saved{maxY}
+	_, err := cp.Load(&saved)
+	aoc.CheckErr(err)
+	defer cp.Done()
+
+	// Rows are scanned in parallel a block at a time, saving progress
+	// between blocks.
+	for top := saved.Y; top >= 0; top -= rowBlock {
+		cp.Tick(func() any { return Saved{top} })
+
+		rows := rowBlock
+		if rows > top+1 {
+			rows = top + 1
+		}
+		x, i, found := aoc.ParallelFind(rows, 0, func(i int) (int, bool) {
+			segments := GetSegments(pairs, top-i).Intervals()
+			if len(segments) > 1 {
+				return segments[1].Lo - 1, true
+			}
+			return 0, false
+		})
+		if found {
+			return top - i + x*4_000_000
 		}
 	}
 
 	return 0
 }
 
-func GetSegments(pairs []*Pair, line int) []Segment {
-	segments := []Segment{}
+// GetSegments finds the parts of a line which are within range of a sensor.
+func GetSegments(pairs []*Pair, line int) interval.IntervalSet {
+	var segments interval.IntervalSet
 	for _, pair := range pairs {
-		segment := pair.GetSegment(line)
-		if segment.length > 0 {
-			segments = segment.Insert(segments)
-		}
+		segments.Insert(pair.GetSegment(line))
 	}
 	return segments
 }
 
-func (this *Pair) GetSegment(y int) Segment {
+func (this *Pair) GetSegment(y int) interval.Interval {
 	// If we have y == this.sensor.y we are at max width
 	maxWidth := 2*this.distance + 1
 
@@ -95,74 +117,11 @@ func (this *Pair) GetSegment(y int) Segment {
 
 	// Too far up or down returns an empty segment
 	if width < 0 {
-		return Segment{}
+		return interval.Empty
 	}
 
 	// We don't care about the beacon for now.
-	segment := Segment{this.sensor.x - width/2, width}
-	return segment
-}
-
-func (this Segment) Insert(in []Segment) []Segment {
-	out := make([]Segment, 0, len(in)+1)
-
-	for len(in) > 0 {
-		comp := this.Compare(&in[0])
-
-		if comp < 0 {
-			// this is entirely before in[0]
-			out = append(out, this)
-			out = append(out, in...)
-			return out
-		}
-		if comp > 0 {
-			// in[0] is entirely before this
-			out = append(out, in[0])
-		} else {
-			// this overlaps in[0]
-			this = this.MergeWith(in[0])
-		}
-		in = in[1:]
-	}
-	out = append(out, this)
-	return out
-}
-
-func (this Segment) String() string {
-	return fmt.Sprintf("<%d..%d>(%d)", this.start, this.End(), this.length)
-}
-
-func (this *Segment) Compare(that *Segment) int {
-	if this.End()+1 < that.start {
-		return -1
-	}
-
-	if that.End()+1 < this.start {
-		return +1
-	}
-
-	return 0
-}
-
-func (this Segment) MergeWith(that Segment) Segment {
-	if this.start > that.start {
-		return that.MergeWith(this)
-	}
-
-	end := this.End()
-	if that.End() > end {
-		end = that.End()
-	}
-
-	return Segment{this.start, end - this.start + 1}
-}
-
-func (this *Segment) Length() int {
-	return this.length
-}
-
-func (this *Segment) End() int {
-	return this.start + this.length - 1
+	return interval.New(this.sensor.x-width/2, this.sensor.x+width/2)
 }
 
 func manhattanDistance(a, b Vec2) int {
@@ -2,8 +2,11 @@ package main
 
 import (
 	"advent-of-code/aoc"
-	"fmt"
+	"advent-of-code/aoc/checkpoint"
+	"advent-of-code/aoc/search"
+	"flag"
 	"regexp"
+	"runtime"
 	"strings"
 )
 
@@ -11,96 +14,109 @@ var InputRegex = regexp.MustCompile("^Valve ([A-Z]{2}) has flow rate=(\\d+); tun
 
 type Valve struct {
 	name string
-	bit uint64
+	index int
 	rate int
 	leadsTo []*Valve
 }
 
 type State struct {
 	valves []*Valve
-	openValves uint64
+	openValves aoc.BitSet
 	time int
 	rate int
 	pressure int
 }
 
-type StateSet map[string]*State
+type StateKey struct {
+	presentValves, openValves aoc.BitSetKey
+}
+
+// SavedState is a State in a form which can be checkpointed, with valves
+// referred to by index.
+type SavedState struct {
+	Valves, OpenValves []int
+	Time, Rate, Pressure int
+}
 
-var seen StateSet
+var checkpointPrefix = flag.String("checkpoint", "", "save progress to, and resume from, files with this prefix")
+var checkpointInterval = flag.Duration("checkpoint-interval", checkpoint.DefaultInterval, "how often to save progress")
 
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
-	aa := parseInput(lines)
+	aa, totalRate := parseInput(lines)
 
-	fmt.Println(run(aa, 1, 30))
-	fmt.Println(run(aa, 2, 26))
+	aoc.PrintAnswer(1, run(aa, totalRate, 1, 30, newCheckpointer(lines, "part1")))
+	aoc.PrintAnswer(2, run(aa, totalRate, 2, 26, newCheckpointer(lines, "part2")))
 }
 
-func run(start *Valve, actors int, endTime int) int {
-	current := make(StateSet)
-	seen = make(StateSet)
-
-	AddState(current, NewState(start, actors))
-
-	for time := 0; time < endTime-1; time++ {
-		for _, state := range current {
-			state.Tick()
-		}
-
-		for i := 0; i < actors; i++ {
-			next := make(StateSet)
-			for _, state := range current {
-				AddState(next, state.OpenValve(i))
+func newCheckpointer(lines []string, part string) *checkpoint.Checkpointer {
+	id := checkpoint.Identify(lines, part)
+	return checkpoint.New(*checkpointPrefix, part, id, *checkpointInterval)
+}
 
-				for _, dest := range state.valves[i].leadsTo {
-					AddState(next, state.MoveTo(i, dest))
-				}
-			}
-			current = next
-		}
+// Each minute is split into one search step per actor. The clock ticks at the
+// start of the first actor's step.
+func run(start *Valve, totalRate int, actors int, endTime int, cp *checkpoint.Checkpointer) int {
+	score := func(state *State) int {
+		return state.pressure + state.rate*(endTime-state.time)
 	}
 
-	max := 0
-	for _, state := range current {
-		finalPressure := state.pressure + state.rate
-		if finalPressure > max {
-			max = finalPressure
-		}
+	problem := search.Problem[*State, StateKey]{
+		Key: (*State).Key,
+		Next: func(state *State, step int, emit func(*State)) {
+			actor := step % actors
+			if actor == 0 {
+				state = state.Tick()
+			}
+			if next := state.OpenValve(actor); next != nil {
+				emit(next)
+			}
+			for _, dest := range state.valves[actor].leadsTo {
+				emit(state.MoveTo(actor, dest))
+			}
+		},
+		Score: score,
+		Bound: func(state *State) int {
+			// Every closed valve opened right now
+			return score(state) + (totalRate-state.rate)*(endTime-state.time)
+		},
+		Memo:    true,
+		Workers: runtime.NumCPU(),
+		OnStep: func(progress search.Progress[*State]) {
+			cp.Tick(func() any {
+				return search.ConvertProgress(progress, (*State).Save)
+			})
+		},
 	}
 
-	return max
-}
+	progress := search.Progress[*State]{Frontier: []*State{NewState(start, actors)}}
 
-func AddState(set StateSet, state* State) {
-	if state == nil {
-		return
+	var saved search.Progress[SavedState]
+	found, err := cp.Load(&saved)
+	aoc.CheckErr(err)
+	if found {
+		valves := start.Reachable()
+		progress = search.ConvertProgress(saved, func(state SavedState) *State {
+			return state.Restore(valves)
+		})
 	}
-	key := state.Key()
 
-	if existing, found := seen[key]; found {
-		if existing.pressure >= state.pressure {
-			return
-		}
-	}
-
-	if existing, found := set[key]; found {
-		if existing.pressure >= state.pressure {
-			return
-		}
-	}
-	set[key] = state
-	seen[key] = state
+	result := problem.Resume(progress, (endTime-1)*actors)
+	cp.Done()
+	return result.Score
 }
 
-func parseInput(lines []string) *Valve {
+func parseInput(lines []string) (*Valve, int) {
 	byName := make(map[string]*Valve)
 	destinations := make(map[string][]string)
+	totalRate := 0
 
 	for i, line := range lines {
 		name, rate, leadsTo := parseLine(line)
-		byName[name] = &Valve{name, 1 << i, rate, make([]*Valve, len(leadsTo))}
+		byName[name] = &Valve{name, i, rate, make([]*Valve, len(leadsTo))}
 		destinations[name] = leadsTo
+		totalRate += rate
 	}
 
 	for name, leadsTo := range destinations {
@@ -110,7 +126,7 @@ func parseInput(lines []string) *Valve {
 		}
 	}
 
-	return byName["AA"]
+	return byName["AA"], totalRate
 }
 
 func parseLine(line string) (string, int, []string) {
@@ -123,17 +139,33 @@ func parseLine(line string) (string, int, []string) {
 	return name, rate, leadsTo
 }
 
+// Reachable lists every valve which can be reached from this one, by index.
+func (this *Valve) Reachable() map[int]*Valve {
+	valves := map[int]*Valve{this.index: this}
+	pending := []*Valve{this}
+	for len(pending) > 0 {
+		valve := pending[0]
+		pending = pending[1:]
+		for _, next := range valve.leadsTo {
+			if _, found := valves[next.index]; !found {
+				valves[next.index] = next
+				pending = append(pending, next)
+			}
+		}
+	}
+	return valves
+}
+
 func NewState(valve *Valve, actors int) *State {
 	valves := make([]*Valve, actors)
 	for i := 0; i < actors; i++ {
 		valves[i] = valve
 	}
-	return &State{ valves, 0, 0, 0, 0 }
+	return &State{ valves, aoc.BitSet{}, 0, 0, 0 }
 }
 
-func (this *State) Tick() {
-	this.time++
-	this.pressure += this.rate
+func (this *State) Tick() *State {
+	return &State{this.valves, this.openValves, this.time + 1, this.rate, this.pressure + this.rate}
 }
 
 func (this *State) MoveTo(index int, dest *Valve) *State {
@@ -150,20 +182,36 @@ func (this *State) OpenValve(index int) *State {
 		return nil
 	}
 
-	if this.openValves & valve.bit == valve.bit {
+	if this.openValves.Has(valve.index) {
 		return nil
 	}
 
-	openValves := this.openValves | valve.bit
+	openValves := this.openValves.With(valve.index)
 	rate := this.rate + valve.rate
 
 	return &State{ this.valves, openValves, this.time, rate, this.pressure }
 }
 
-func (this *State) Key() string {
-	presentValves := uint64(0)
+func (this *State) Save() SavedState {
+	valves := make([]int, len(this.valves))
+	for i, valve := range this.valves {
+		valves[i] = valve.index
+	}
+	return SavedState{valves, this.openValves.Members(), this.time, this.rate, this.pressure}
+}
+
+func (this SavedState) Restore(byIndex map[int]*Valve) *State {
+	valves := make([]*Valve, len(this.Valves))
+	for i, index := range this.Valves {
+		valves[i] = byIndex[index]
+	}
+	return &State{valves, aoc.NewBitSet(this.OpenValves...), this.Time, this.Rate, this.Pressure}
+}
+
+func (this *State) Key() StateKey {
+	var presentValves aoc.BitSet
 	for _, valve := range this.valves {
-		presentValves |= valve.bit
+		presentValves.Set(valve.index)
 	}
-	return fmt.Sprintf("%x/%x", presentValves, this.openValves)
+	return StateKey{ presentValves.Key(), this.openValves.Key() }
 }
@@ -1,17 +1,20 @@
 package main
 
-
 // 3178 too low
 
 import (
 	"advent-of-code/aoc"
-	"bufio"
+	"advent-of-code/aoc/checkpoint"
+	"advent-of-code/aoc/explore"
+	"advent-of-code/aoc/sim"
+	"flag"
 	"fmt"
 	"os"
-	"time"
 )
 
-const debug = !true
+var interactive = flag.Bool("explore", false, "step through part 1 interactively")
+var checkpointPrefix = flag.String("checkpoint", "", "save progress to, and resume from, files with this prefix")
+var checkpointInterval = flag.Duration("checkpoint-interval", checkpoint.DefaultInterval, "how often to save progress")
 
 type Vec2 struct {
 	x, y int
@@ -20,72 +23,107 @@ type Vec2 struct {
 type Piece []Vec2
 
 type Chamber struct {
-	cell map[Vec2] bool
+	cell   map[Vec2]bool
 	height int
+
+	pieces []Piece
+	moves  string
+	move   int
+	drops  []Drop
 }
 
-func main() {
-	filename := aoc.GetFilename()
-	input := aoc.Slurp(filename)
+// Drop records a piece coming to rest, so that it can be undone.
+type Drop struct {
+	cells        []Vec2
+	height, move int
+}
 
-	for {
-		last := len(input)-1
-		if input[last] == '<' || input[last] == '>' {
-			break
-		}
-		input = input[:last]
-	}
+// Saved is a chamber in a form which can be checkpointed.
+type Saved struct {
+	Drops        []SavedDrop
+	Height, Move int
+}
 
-	fmt.Println(part1(input))
-	fmt.Println(part2(input))
+type SavedDrop struct {
+	Cells        [][2]int
+	Height, Move int
 }
 
-func part1(input string) int {
-	pieces := makePieces()
-	chamber := makeChamber()
+// SavedSearch is part 2 part way through its search for a cycle.
+type SavedSearch struct {
+	Chamber Saved
+	Trace   sim.Trace[Fingerprint]
+}
 
-	move := 0
+// Fingerprint is the next piece and move, and the shape of the surface the
+// piece will land on.
+type Fingerprint struct {
+	Piece, Move int
+	Surface     [7]int
+}
 
-	for i := 0; i < 2022; i++ {
-		piece := pieces[i % len(pieces)]
-		move = chamber.dropPiece(&piece, input, move)
-		if debug {
-			bufio.NewReader(os.Stdin).ReadBytes('\n')
-		}
-	}
+// How far down the surface is followed before giving up on a column.
+const surfaceDepth = 64
 
-	return chamber.height
-}
+const inputRules = aoc.Normalise | aoc.StripFinalNewline
 
-// Actual height after 100k pieces = 156632
-// Wrapped at round 19664, piece 98320: 10077 -> 18. New pieces=1725 height=2702 (154004).
-// Wrapped at round 20009, piece 100045: 10077 -> 18. New pieces=1725 height=2702 (156706).
+func main() {
+	filename := aoc.GetFilename()
+	input, changes := aoc.SlurpNormalised(filename, inputRules)
+	aoc.ReportInputChanges(filename, changes)
 
-func part2(moves string) int {
-	pieces := makePieces()
-	chamber := makeChamber()
+	if *interactive {
+		aoc.RefuseJSON("-explore")
+		explorePart1(input)
+		return
+	}
 
-	startRounds, startMove := chamber.runLoop(pieces, moves, 0)
-	startHeight := chamber.height
+	id := checkpoint.Identify(input, "part2")
+	aoc.PrintAnswer(1, part1(input))
+	aoc.PrintAnswer(2, part2(input, checkpoint.New(*checkpointPrefix, "part2", id, *checkpointInterval)))
+}
 
-	loopRounds, loopMove := chamber.runLoop(pieces, moves, startMove)
-	loopHeight := chamber.height - startHeight
+func part1(input string) int {
+	chamber := makeChamber(makePieces(), input)
+	sim.Run(chamber, 2022)
+	return chamber.height
+}
 
-	targetPieces := 1_000_000_000_000
-	targetRounds := targetPieces / len(pieces)
+func explorePart1(input string) {
+	chamber := makeChamber(makePieces(), input)
 
-	loops := (targetRounds - startRounds) / loopRounds
+	explorer := explore.New[int](chamber)
+	explorer.AddCondition("height", "H: until the tower is at least H high", func(args []int) bool {
+		return len(args) > 0 && chamber.height >= args[0]
+	})
+	aoc.CheckErr(explorer.Run(os.Stdin, os.Stdout))
+}
 
-	extraRounds := targetRounds - (startRounds + loops * loopRounds)
+// part2 can take a long time to find a cycle when there are many moves, so
+// its progress can be checkpointed.
+func part2(moves string, cp *checkpoint.Checkpointer) int {
+	chamber := makeChamber(makePieces(), moves)
 
-	move := loopMove
-	for i := 0; i < extraRounds; i++ {
-		move = chamber.doRound(pieces, moves, move)
+	var saved SavedSearch
+	found, err := cp.Load(&saved)
+	aoc.CheckErr(err)
+	if found {
+		chamber.Load(saved.Chamber)
 	}
+	trace := &saved.Trace
 
-	totalHeight := loopHeight * (loops - 1) + chamber.height
+	targetPieces := 1_000_000_000_000
+	height, found := sim.ExtrapolateFrom[Fingerprint](chamber, trace, targetPieces, 0, func() int {
+		return chamber.height
+	}, func() {
+		cp.Tick(func() any { return SavedSearch{chamber.Save(), *trace} })
+	})
+	if !found {
+		panic("no cycle")
+	}
+	cp.Done()
 
-	return totalHeight
+	return height
 }
 
 func makePieces() []Piece {
@@ -98,50 +136,103 @@ func makePieces() []Piece {
 	}
 }
 
-func makeChamber() *Chamber {
-	return &Chamber{ make(map[Vec2]bool), 0 }
+func makeChamber(pieces []Piece, moves string) *Chamber {
+	return &Chamber{cell: make(map[Vec2]bool), pieces: pieces, moves: moves}
 }
 
-func (this *Chamber) runLoop(pieces []Piece, moves string, startMove int) (round, move int) {
-	round = 0
-	move = startMove
-	for {
-		move = this.doRound(pieces, moves, move)
-		round++
-		if move < startMove {
-			return
+// Step drops the next piece.
+func (this *Chamber) Step() bool {
+	piece := this.pieces[len(this.drops)%len(this.pieces)]
+	drop := Drop{height: this.height, move: this.move}
+
+	var offset Vec2
+	this.move, offset = this.dropPiece(&piece, this.moves, this.move)
+
+	for _, p := range piece {
+		drop.cells = append(drop.cells, p.add(offset))
+	}
+	this.drops = append(this.drops, drop)
+	return true
+}
+
+func (this *Chamber) Fingerprint() Fingerprint {
+	fp := Fingerprint{Piece: len(this.drops) % len(this.pieces), Move: this.move}
+	for x := range fp.Surface {
+		depth := 0
+		for depth < surfaceDepth && this.isClear(Vec2{x, this.height - depth - 1}) {
+			depth++
+		}
+		fp.Surface[x] = depth
+	}
+	return fp
+}
+
+// Snapshot is the number of pieces dropped. Restoring it undoes or replays
+// drops as needed.
+func (this *Chamber) Snapshot() int {
+	return len(this.drops)
+}
+
+func (this *Chamber) Restore(dropped int) {
+	for len(this.drops) > dropped {
+		drop := this.drops[len(this.drops)-1]
+		for _, p := range drop.cells {
+			delete(this.cell, p)
+		}
+		this.height = drop.height
+		this.move = drop.move
+		this.drops = this.drops[:len(this.drops)-1]
+	}
+	for len(this.drops) < dropped {
+		this.Step()
+	}
+}
+
+func (this *Chamber) Save() Saved {
+	saved := Saved{Height: this.height, Move: this.move}
+	for _, drop := range this.drops {
+		cells := make([][2]int, len(drop.cells))
+		for i, p := range drop.cells {
+			cells[i] = [2]int{p.x, p.y}
 		}
-		startMove = move
+		saved.Drops = append(saved.Drops, SavedDrop{cells, drop.height, drop.move})
 	}
+	return saved
 }
 
-func (this *Chamber) doRound(pieces []Piece, moves string, move int) int {
-	for _, piece := range pieces {
-		move = this.dropPiece(&piece, moves, move)
+func (this *Chamber) Load(saved Saved) {
+	this.cell = make(map[Vec2]bool)
+	this.drops = nil
+	for _, s := range saved.Drops {
+		drop := Drop{height: s.Height, move: s.Move}
+		for _, c := range s.Cells {
+			p := Vec2{c[0], c[1]}
+			drop.cells = append(drop.cells, p)
+			this.cell[p] = true
+		}
+		this.drops = append(this.drops, drop)
 	}
-	return move
+	this.height = saved.Height
+	this.move = saved.Move
 }
 
-func (this *Chamber) dropPiece(piece *Piece, moves string, move int) int {
+func (this *Chamber) dropPiece(piece *Piece, moves string, move int) (int, Vec2) {
 	down := Vec2{0, -1}
 	offset := Vec2{2, this.height + 3}
-	this.printWith(piece, offset)
 	for {
 		wind := delta(moves[move])
 		move = (move + 1) % len(moves)
 		if this.canPlace(piece, offset.add(wind)) {
 			offset = offset.add(wind)
-			this.printWith(piece, offset)
 		}
 
 		if !this.canPlace(piece, offset.add(down)) {
 			break
 		}
 		offset = offset.add(down)
-		this.printWith(piece, offset)
 	}
 	this.place(piece, offset)
-	return move
+	return move, offset
 }
 
 func (this *Chamber) isClear(p Vec2) bool {
@@ -166,70 +257,40 @@ func (this *Chamber) place(piece *Piece, offset Vec2) {
 	for _, p := range *piece {
 		p = p.add(offset)
 		if p.y+1 > this.height {
-			this.height = p.y+1
+			this.height = p.y + 1
 		}
 		this.cell[p] = true
 	}
 }
 
-func (this *Chamber) printWith(piece *Piece, offset Vec2) {
-	if !debug {
-		return
-	}
-	fmt.Print("\x1b[H\x1b[J")
-	fmt.Println(this.height)
-	extra := make(map[Vec2]bool)
-	for _, p := range *piece {
-		p = p.add(offset)
-		extra[p] = true
-	}
-
-	for y := this.height + 5; y >= 0; y-- {
-		fmt.Print("#")
-		for x := 0; x <= 6; x++ {
-			v := Vec2{x, y}
-			if _, onPiece := extra[v]; onPiece {
-				fmt.Print("O")
-			} else if this.isClear(Vec2{x, y}) {
-				fmt.Print(".")
-			} else {
-				fmt.Print("#")
-			}
-		}
-		fmt.Println("#")
+// The explorer shows the chamber top down, with room above the surface for
+// the next piece to appear.
+func (this *Chamber) Bounds() (minX, minY, maxX, maxY int) {
+	return -1, 0, 7, this.top() + 1
+}
 
-		if this.height - y > 20 {
-			fmt.Println("#~~~~~~~#\n")
-			time.Sleep(100 * time.Millisecond)
-			return
-		}
+func (this *Chamber) Cell(x, row int) rune {
+	y := this.top() - row
+	switch {
+	case y < 0 && (x < 0 || x > 6):
+		return '+'
+	case y < 0:
+		return '-'
+	case x < 0 || x > 6:
+		return '|'
+	case this.isClear(Vec2{x, y}):
+		return '.'
+	default:
+		return '#'
 	}
-	fmt.Println("#########\n")
-	time.Sleep(100 * time.Millisecond)
-}
-
-func (this *Chamber) print() {
-	fmt.Print("\x1b[H\x1b[J")
-	fmt.Println(this.height)
-	for y := this.height; y >= 0; y-- {
-		fmt.Print("#")
-		for x := 0; x <= 6; x++ {
-			if this.isClear(Vec2{x, y}) {
-				fmt.Print(".")
-			} else {
-				fmt.Print("#")
-			}
-		}
-		fmt.Println("#")
+}
 
-		if this.height - y > 20 {
-			fmt.Println("#~~~~~~~#\n")
-			time.Sleep(500 * time.Millisecond)
-			return
-		}
-	}
-	fmt.Println("#########\n")
-	time.Sleep(500 * time.Millisecond)
+func (this *Chamber) Status() string {
+	return fmt.Sprintf("pieces %d  height %d", len(this.drops), this.height)
+}
+
+func (this *Chamber) top() int {
+	return this.height + 6
 }
 
 func (this Vec2) add(that Vec2) Vec2 {
@@ -239,9 +300,12 @@ func (this Vec2) add(that Vec2) Vec2 {
 func delta(move byte) Vec2 {
 	switch move {
 
-	case '<': return Vec2{-1, 0}
-	case '>': return Vec2{+1, 0}
+	case '<':
+		return Vec2{-1, 0}
+	case '>':
+		return Vec2{+1, 0}
 
-	default: panic(move)
+	default:
+		panic(move)
 	}
 }
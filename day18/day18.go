@@ -2,155 +2,71 @@ package main
 
 import (
 	"advent-of-code/aoc"
-	"fmt"
-	"math"
 	"strings"
 )
 
-type Vec3 [3]int
-
-type BBox struct {
-	min Vec3
-	max Vec3
-}
-
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	cubeMap := make(map[Vec3]bool)
-	for _, line := range lines {
-		cube := parseCube(line)
-		cubeMap[cube] = true
+	cubes := make([]aoc.Vec3, len(lines))
+	for i, line := range lines {
+		cubes[i] = parseCube(line)
 	}
+	droplet := makeDroplet(cubes)
 
-	fmt.Println(part1(cubeMap))
-	fmt.Println(part2(cubeMap))
+	aoc.PrintAnswer(1, part1(droplet))
+	aoc.PrintAnswer(2, part2(droplet))
 }
 
-func part1(cubeMap map[Vec3]bool) int {
+func part1(droplet *aoc.Grid3[bool]) int {
 	surfaceArea := 0
-	for pos := range cubeMap {
-		for axis := range pos {
-			before := pos
-			before[axis]--
-			if !cubeMap[before] {
-				surfaceArea++
-			}
-
-			after := pos
-			after[axis]++
-			if !cubeMap[after] {
+	droplet.Each(func(pos aoc.Vec3, cube bool) {
+		if !cube {
+			return
+		}
+		for _, n := range pos.Neighbours6() {
+			if !droplet.Get(n) {
 				surfaceArea++
 			}
 		}
-	}
+	})
 	return surfaceArea
 }
 
-func part2(cubeMap map[Vec3]bool) int {
-	volume := makeVolume(cubeMap)
-	seen := make(map[Vec3]bool)
+func part2(droplet *aoc.Grid3[bool]) int {
 	surfaceArea := 0
 
-	checkCell := func(pos Vec3) bool {
-		if !volume.contains(pos) {
-			return false
-		}
-
-		if seen[pos] {
-			return false
-		}
-
-		if cubeMap[pos] {
-			// Gone from empty space to inside cube. We've crossed a new surface
-			// area face.
-			surfaceArea++
-			return false
-		}
-
-		// Still in unseen empty space
-		return true
+	isEmpty := func(pos aoc.Vec3, cube bool) bool {
+		return !cube
 	}
-
-	var floodFill func(Vec3)
-	floodFill = func(pos Vec3) {
-		seen[pos] = true // mark this one off
-
-		for axis := range pos {
-			before := pos
-			before[axis]--
-			if checkCell(before) {
-				floodFill(before)
-			}
-
-			after := pos
-			after[axis]++
-			if checkCell(after) {
-				floodFill(after)
+	droplet.FloodFill(droplet.Min(), isEmpty, func(pos aoc.Vec3) {
+		for _, n := range pos.Neighbours6() {
+			if cube, _ := droplet.GetMaybe(n); cube {
+				// Gone from empty space to inside cube. We've crossed a new
+				// surface area face.
+				surfaceArea++
 			}
 		}
-	}
-	floodFill(volume.min)
+	})
 
 	return surfaceArea
 }
 
-func makeVolume(cubes map[Vec3]bool) BBox {
-	bbox := newBBox()
-	for cube := range cubes {
-		bbox = bbox.extend(cube)
-	}
-
-	for i := range bbox.min {
-		bbox.min[i]--
-		bbox.max[i]++
+// makeDroplet fills a grid with the cubes, leaving a layer of empty space all
+// round so that the outside is connected.
+func makeDroplet(cubes []aoc.Vec3) *aoc.Grid3[bool] {
+	min, max := aoc.Bounds3(cubes)
+	droplet := aoc.NewGrid3(min.Add(aoc.Vec3{-1, -1, -1}), max.Add(aoc.Vec3{1, 1, 1}), false)
+	for _, cube := range cubes {
+		droplet.Set(cube, true)
 	}
-
-	return bbox
+	return droplet
 }
 
-func parseCube(line string) Vec3 {
+func parseCube(line string) aoc.Vec3 {
 	coords := aoc.ParseInts(strings.Split(line, ","))
-	var p Vec3
+	var p aoc.Vec3
 	copy(p[:], coords)
 	return p
 }
-
-func newBBox() BBox {
-	min := [3]int{ math.MaxInt, math.MaxInt, math.MaxInt }
-	max := [3]int{ math.MinInt, math.MinInt, math.MinInt }
-	return BBox{ min, max }
-}
-
-func (this BBox) extend(p Vec3) BBox {
-	var bbox BBox
-	for axis, value := range p {
-		bbox.min[axis] = min(this.min[axis], value)
-		bbox.max[axis] = max(this.max[axis], value)
-	}
-	return bbox
-}
-
-func (this BBox) contains(p Vec3) bool {
-	for axis, value := range p {
-		if value < this.min[axis] || value > this.max[axis] {
-			return false
-		}
-	}
-	return true
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"advent-of-code/aoc"
-	"fmt"
+	"advent-of-code/aoc/checkpoint"
+	"advent-of-code/aoc/search"
+	"flag"
 	"regexp"
 )
 
@@ -30,8 +32,25 @@ type Blueprint struct {
 type State struct {
 	materialCount [MaterialCount]uint16
 	robotCount [MaterialCount]uint16
+	minute uint16
 }
 
+// SavedState is a State in a form which can be checkpointed.
+type SavedState struct {
+	Materials, Robots [MaterialCount]uint16
+	Minute uint16
+}
+
+// Saved is the progress through a list of blueprints: the geodes from those
+// finished, and the search of the next.
+type Saved struct {
+	Geodes []int
+	Current search.Progress[SavedState]
+}
+
+var checkpointPrefix = flag.String("checkpoint", "", "save progress to, and resume from, files with this prefix")
+var checkpointInterval = flag.Duration("checkpoint-interval", checkpoint.DefaultInterval, "how often to save progress")
+
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
@@ -41,45 +60,88 @@ func main() {
 		blueprints[i] = parseBlueprint(line)
 	}
 
-	fmt.Println(part1(blueprints))
-	fmt.Println(part2(blueprints[0:3]))
+	aoc.PrintAnswer(1, part1(blueprints, newCheckpointer(lines, "part1")))
+	aoc.PrintAnswer(2, part2(blueprints[0:3], newCheckpointer(lines, "part2")))
+}
+
+func newCheckpointer(lines []string, part string) *checkpoint.Checkpointer {
+	id := checkpoint.Identify(lines, part)
+	return checkpoint.New(*checkpointPrefix, part, id, *checkpointInterval)
 }
 
-func part1(blueprints []Blueprint) int {
+func part1(blueprints []Blueprint, cp *checkpoint.Checkpointer) int {
 	totalQuality := 0
-	for i, blueprint := range blueprints {
-		quality := (i + 1) * runBlueprint(blueprint, 24)
+	for i, geodes := range runBlueprints(blueprints, 24, cp) {
+		quality := (i + 1) * geodes
 		totalQuality += quality
 	}
 	return totalQuality
 }
 
-func part2(blueprints []Blueprint) int {
+func part2(blueprints []Blueprint, cp *checkpoint.Checkpointer) int {
 	result := 1
-	for _, blueprint := range blueprints {
-		result *= runBlueprint(blueprint, 32)
+	for _, geodes := range runBlueprints(blueprints, 32, cp) {
+		result *= geodes
 	}
 	return result
 }
 
+// runBlueprints finds the most geodes each blueprint can open. The
+// blueprints are independent, so are run in parallel unless checkpointing,
+// which needs them one at a time to pick up from any saved progress.
+func runBlueprints(blueprints []Blueprint, minutes int, cp *checkpoint.Checkpointer) []int {
+	if !cp.Enabled() {
+		return aoc.ParallelMap(len(blueprints), 0, func(i int) int {
+			start := search.Progress[State]{Frontier: []State{startState()}}
+			return runBlueprint(blueprints[i], minutes, start, nil)
+		})
+	}
+
+	var saved Saved
+	_, err := cp.Load(&saved)
+	aoc.CheckErr(err)
+
+	for i := len(saved.Geodes); i < len(blueprints); i++ {
+		progress := search.Progress[State]{Frontier: []State{startState()}}
+		if len(saved.Current.Frontier) > 0 {
+			progress = search.ConvertProgress(saved.Current, SavedState.Restore)
+		}
+
+		geodes := runBlueprint(blueprints[i], minutes, progress, func(progress search.Progress[State]) {
+			cp.Tick(func() any {
+				return Saved{saved.Geodes, search.ConvertProgress(progress, State.Save)}
+			})
+		})
+
+		saved.Geodes = append(saved.Geodes, geodes)
+		saved.Current = search.Progress[SavedState]{}
+	}
+
+	cp.Done()
+	return saved.Geodes
+}
+
 // 3096 is too low
 
-func runBlueprint(blueprint Blueprint, minutes int) int {
-	currentStates := make(map[State]bool)
-	currentStates[startState()] = true
-	nextStates := make(map[State]bool)
+func runBlueprint(blueprint Blueprint, minutes int, progress search.Progress[State], onStep func(search.Progress[State])) int {
+	remaining := func(state State) int {
+		return minutes - int(state.minute)
+	}
+	score := func(state State) int {
+		return int(state.materialCount[Geode]) + int(state.robotCount[Geode])*remaining(state)
+	}
 
-	for minute := 1; minute <= minutes; minute++ {
-		//fmt.Printf("Minute %d: states %d\n", minute, len(currentStates))
-		for state := range currentStates {
+	problem := search.Problem[State, State]{
+		Key: func(state State) State { return state },
+		Next: func(state State, step int, emit func(State)) {
 			if state.canBuild(blueprint, Geode, 1) {
-				nextStates[ state.build(blueprint, Geode) ] = true
-				continue
+				emit(state.build(blueprint, Geode))
+				return
 			}
 
 			if state.canBuild(blueprint, Obsidian, 1) {
-				nextStates[ state.build(blueprint, Obsidian) ] = true
-				continue
+				emit(state.build(blueprint, Obsidian))
+				return
 			}
 			for material := Material(0); material <= Clay; material++ {
 				// Can't build a robot if you can't afford it.
@@ -87,22 +149,22 @@ func runBlueprint(blueprint Blueprint, minutes int) int {
 				if !state.canBuild(blueprint, material, 1) || state.canBuild(blueprint, material, 2) {
 					continue
 				}
-				nextStates[ state.build(blueprint, material) ] = true
+				emit(state.build(blueprint, material))
 			}
-			nextStates[state.step()] = true
-		}
-		currentStates = nextStates
-		nextStates = make(map[State]bool)
+			emit(state.step())
+		},
+		Score: score,
+		Bound: func(state State) int {
+			// A new geode robot every remaining minute
+			n := remaining(state)
+			return score(state) + n*(n-1)/2
+		},
+		OnStep: onStep,
 	}
 
-	max := uint16(0)
-	for state := range currentStates {
-		if state.materialCount[Geode] > max {
-			max = state.materialCount[Geode]
-		}
-	}
-	//fmt.Println("Max =", max)
-	return int(max)
+	result := problem.Resume(progress, minutes)
+	//fmt.Printf("Max = %d, explored %d\n", result.Score, result.Explored)
+	return result.Score
 }
 
 func parseBlueprint(line string) Blueprint {
@@ -126,6 +188,14 @@ func startState() State {
 	return state
 }
 
+func (this State) Save() SavedState {
+	return SavedState{this.materialCount, this.robotCount, this.minute}
+}
+
+func (this SavedState) Restore() State {
+	return State{this.Materials, this.Robots, this.Minute}
+}
+
 func (this State) canBuild(bp Blueprint, robot Material, count uint16) bool {
 	for material, cost := range bp.robot[robot].cost {
 		if cost * count > this.materialCount[material] {
@@ -143,6 +213,7 @@ func (this State) build(bp Blueprint, robot Material) State {
 		this.materialCount[material] += count
 	}
 	this.robotCount[robot]++
+	this.minute++
 	return this
 }
 
@@ -150,5 +221,6 @@ func (this State) step() State {
 	for material, count := range this.robotCount {
 		this.materialCount[material] += count
 	}
+	this.minute++
 	return this
 }
@@ -2,8 +2,9 @@ package main
 
 import (
 	"advent-of-code/aoc"
-	"fmt"
-	"strings"
+	"advent-of-code/aoc/mathx"
+	"advent-of-code/aoc/parse"
+	"log"
 )
 
 type Operator string
@@ -27,8 +28,8 @@ func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
 }
 
 func part1(lines []string) int {
@@ -115,10 +116,10 @@ func part2(lines []string) int {
 			switch exp.operator {
 
 			// A + x = B =>  x = B - A
-			case "+": return solve(subValue, rhs - innerLhs)
+			case "+": return solve(subValue, mathx.MustSub(rhs, innerLhs))
 
 			// A - x = B => x = A - B
-			case "-": return solve(subValue, innerLhs - rhs)
+			case "-": return solve(subValue, mathx.MustSub(innerLhs, rhs))
 
 			// A * x = B =>  x = B / A
 			case "*": return solve(subValue, rhs / innerLhs)
@@ -135,16 +136,16 @@ func part2(lines []string) int {
 			switch exp.operator {
 
 			// x + A = B =>  x = B - A
-			case "+": return solve(subValue, rhs - innerRhs)
+			case "+": return solve(subValue, mathx.MustSub(rhs, innerRhs))
 
 			// x - A = B => x = A + B
-			case "-": return solve(subValue, innerRhs + rhs)
+			case "-": return solve(subValue, mathx.MustAdd(innerRhs, rhs))
 
 			// a * A = B =>  x = B / A
 			case "*": return solve(subValue, rhs / innerRhs)
 
 			// x / A = B => x = A * B
-			case "/": return solve(subValue, innerRhs * rhs)
+			case "/": return solve(subValue, mathx.MustMul(innerRhs, rhs))
 
 			}
 		}
@@ -165,27 +166,54 @@ func part2(lines []string) int {
 func doOperator(operator Operator, lhs, rhs int) int {
 	switch (operator) {
 
-	case "+": return lhs + rhs
-	case "-": return lhs - rhs
-	case "*": return lhs * rhs
+	case "+": return mathx.MustAdd(lhs, rhs)
+	case "-": return mathx.MustSub(lhs, rhs)
+	case "*": return mathx.MustMul(lhs, rhs)
 	case "/": return lhs / rhs
 	default: panic(operator)
 
 	}
 }
 
-func parseMonkey(line string) (string, Monkey) {
-	words := strings.Split(line, " ")
-	name := words[0]
-	name = name[:len(name)-1] // drop the colon
+// monkey = name ": " ( int | name " " operator " " name )
+var monkeyParser = parse.Choice(
+	parse.Map(parse.Int(), func(value int) Monkey {
+		return Monkey(value)
+	}),
+	func(s *parse.Scanner) (Monkey, error) {
+		lhs, err := parse.Letters()(s)
+		if err != nil {
+			return nil, err
+		}
+		operator, err := parse.Between(
+			parse.Literal(" "),
+			parse.Choice(parse.Literal("+"), parse.Literal("-"), parse.Literal("*"), parse.Literal("/")),
+			parse.Literal(" "),
+		)(s)
+		if err != nil {
+			return nil, err
+		}
+		rhs, err := parse.Letters()(s)
+		if err != nil {
+			return nil, err
+		}
+		return Monkey(Operation{ lhs, rhs, Operator(operator) }), nil
+	},
+)
 
-	if len(words) == 2 {
-		return name, Monkey(aoc.ParseInt(words[1]))
-	}
+var nameParser = parse.Skip(parse.Letters(), parse.Literal(": "))
 
-	return name, Monkey(Operation{
-		lhs: words[1],
-		rhs: words[3],
-		operator: Operator(words[2]),
-	})
+func parseMonkey(line string) (string, Monkey) {
+	var name string
+	monkey, err := parse.Parse(func(s *parse.Scanner) (Monkey, error) {
+		var err error
+		if name, err = nameParser(s); err != nil {
+			return nil, err
+		}
+		return monkeyParser(s)
+	}, line)
+	if err != nil {
+		log.Fatalf("%q: %v", line, err)
+	}
+	return name, monkey
 }
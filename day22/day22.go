@@ -2,7 +2,8 @@ package main
 
 import (
 	"advent-of-code/aoc"
-	"fmt"
+	"advent-of-code/aoc/parse"
+	"log"
 )
 
 type Tile rune
@@ -12,25 +13,38 @@ const (
 	Wall  Tile = '#'
 )
 
-type Span struct {
-	offset, size int
-}
-
 type Map struct {
-	tiles Grid[Tile]
-	rows []Span
-	cols []Span
+	tiles    *aoc.Grid[Tile]
+	topology aoc.Topology
 }
 
 type Vec2 struct {
 	x, y int
 }
 
+// Instruction is either a distance to move or a turn left (-1) or right (+1).
+type Instruction struct {
+	distance, turn int
+}
+
+// moves = ( distance | "L" | "R" )*
+var instructionsParser = parse.Many(parse.Choice(
+	parse.Map(parse.Uint(), func(distance int) Instruction {
+		return Instruction{ distance: distance }
+	}),
+	parse.Map(parse.Literal("L"), func(string) Instruction {
+		return Instruction{ turn: -1 }
+	}),
+	parse.Map(parse.Literal("R"), func(string) Instruction {
+		return Instruction{ turn: +1 }
+	}),
+))
+
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
+	aoc.PrintAnswer(1, part1(lines))
 }
 
 func part1(lines []string) int {
@@ -52,213 +66,61 @@ func part1(lines []string) int {
 		}
 	}
 
-	// Build row spans
-	for y := 0; y < height; y++ {
-		x := 0
-		for m.tiles.Get(x, y) == Empty {
-			x++
-		}
-		m.rows[y].offset = x
-		for m.tiles.Get(x, y) != Empty {
-			x++
-		}
-		m.rows[y].size = x - m.rows[y].offset
+	topology, err := aoc.NewRaggedWrap(m.tiles, func(tile Tile) bool {
+		return tile != Empty
+	})
+	if err != nil {
+		log.Fatalf("map: %v", err)
 	}
+	m.topology = topology
 
-	// Build col spans
-	for x := 0; x < width; x++ {
-		y := 0
-		for m.tiles.Get(x, y) == Empty {
-			y++
-		}
-		m.cols[x].offset = y
-		for m.tiles.Get(x, y) != Empty {
-			y++
-		}
-		m.cols[x].size = y - m.cols[x].offset
+	instructions, err := parse.Parse(instructionsParser, moves)
+	if err != nil {
+		log.Fatalf("moves: %v", err)
 	}
 
-	move := [...]func(*Map, Vec2, int) Vec2{
-		MoveRight,
-		MoveDown,
-		MoveLeft,
-		MoveUp,
+	pos := Vec2{ 0, 0 }
+	for m.tiles.Get(pos.x, pos.y) == Empty {
+		pos.x++
 	}
-	//dirname := [...]string{ "right", "down", "left", "up" }
+	dir := aoc.East
 
-	pos := Vec2{ m.rows[0].offset, 0 }
-	dir := 0
-
-	var distance, turn int
-
-	for len(moves) > 0 {
-		distance, moves = getMoveDistance(moves)
-		newPos := move[dir](&m, pos, distance)
-		//fmt.Printf("Move %d %s from %d,%d to %d,%d\n", distance, dirname[dir], pos.x, pos.y, newPos.x, newPos.y)
-		pos = newPos
-
-		if len(moves) == 0 {
-			break
+	for _, instruction := range instructions {
+		if instruction.turn != 0 {
+			dir = dir.Turn(instruction.turn)
+			continue
 		}
-
-		turn, moves = getTurn(moves)
-		dir = (dir + len(move) + turn) % len(move)
+		newPos, newDir := m.Move(pos, dir, instruction.distance)
+		//fmt.Printf("Move %d %v from %d,%d to %d,%d\n", instruction.distance, dir, pos.x, pos.y, newPos.x, newPos.y)
+		pos, dir = newPos, newDir
 	}
 
 	//fmt.Printf("Final row=%d col=%d dir=%d\n", pos.y + 1, pos.x + 1, dir)
 
-	return 1000 * (pos.y + 1) + 4 * (pos.x + 1) + dir
-}
-
-func MoveUp(m *Map, pos Vec2, count int) Vec2 {
-	// count %= m.rows[pos.y].size
-	col := m.cols[pos.x]
-	for i := 0; i < count; i++ {
-		nextPos := Vec2{ pos.x, pos.y - 1 }
-		if nextPos.y < col.offset {
-			nextPos.y = col.offset + col.size - 1
-		}
-
-		switch m.tiles.Get(nextPos.x, nextPos.y) {
-			case Wall: return pos
-			case Open: pos = nextPos
-			case Empty: panic(nextPos)
-		}
-	}
-	return pos
-}
-
-func MoveDown(m *Map, pos Vec2, count int) Vec2 {
-	// count %= m.rows[pos.y].size
-	col := m.cols[pos.x]
-	for i := 0; i < count; i++ {
-		nextPos := Vec2{ pos.x, pos.y + 1 }
-		if nextPos.y >= col.offset + col.size {
-			nextPos.y = col.offset
-		}
-
-		switch m.tiles.Get(nextPos.x, nextPos.y) {
-			case Wall: return pos
-			case Open: pos = nextPos
-			case Empty: panic(nextPos)
-		}
-	}
-	return pos
-}
-
-
-func MoveLeft(m *Map, pos Vec2, count int) Vec2 {
-	// count %= m.rows[pos.y].size
-	row := m.rows[pos.y]
-	for i := 0; i < count; i++ {
-		nextPos := Vec2{ pos.x - 1, pos.y }
-		if nextPos.x < row.offset {
-			nextPos.x = row.offset + row.size - 1
-		}
-
-		switch m.tiles.Get(nextPos.x, nextPos.y) {
-			case Wall: return pos
-			case Open: pos = nextPos
-			case Empty: panic(nextPos)
-		}
-	}
-	return pos
+	return 1000 * (pos.y + 1) + 4 * (pos.x + 1) + int(dir)
 }
 
-func MoveRight(m *Map, pos Vec2, count int) Vec2 {
-	// count %= m.rows[pos.y].size
-	row := m.rows[pos.y]
+// Move goes up to count steps, stopping at any wall. The topology decides
+// where each step leads, and which way we face afterwards.
+func (m *Map) Move(pos Vec2, dir aoc.Dir, count int) (Vec2, aoc.Dir) {
 	for i := 0; i < count; i++ {
-		nextPos := Vec2{ pos.x + 1, pos.y }
-		if nextPos.x >= row.offset + row.size {
-			nextPos.x = row.offset
+		x, y, nextDir, ok := m.topology.Step(pos.x, pos.y, dir)
+		if !ok {
+			panic(pos)
 		}
+		nextPos := Vec2{ x, y }
 
 		switch m.tiles.Get(nextPos.x, nextPos.y) {
-			case Wall: return pos
-			case Open: pos = nextPos
+			case Wall: return pos, dir
+			case Open: pos, dir = nextPos, nextDir
 			case Empty: panic(nextPos)
 		}
 	}
-	return pos
+	return pos, dir
 }
 
 func NewMap(width, height int) Map {
 	return Map{
-		tiles: NewGrid[Tile](width, height, Empty),
-		rows: make([]Span, height),
-		cols: make([]Span, width),
-	}
-}
-
-func getMoveDistance(moves string) (int, string) {
-	distance := 0
-
-	for len(moves) > 0 && moves[0] >= '0' && moves[0] <= '9' {
-		distance = distance * 10 + int(moves[0] - '0')
-		moves = moves[1:]
+		tiles: aoc.NewGrid[Tile](width, height, Empty),
 	}
-
-	return distance, moves
-}
-
-func getTurn(moves string) (int, string) {
-	turn := moves[0]
-	moves = moves[1:]
-
-	//fmt.Printf("Turn %c\n", turn)
-
-	switch turn {
-		case 'L': return -1, moves
-		case 'R': return +1, moves
-		default: panic(turn)
-	}
-}
-
-type Grid[T any] struct {
-    w, h int
-	empty T
-    cell []T
-}
-
-func NewGrid[T any](w, h int, empty T) Grid[T] {
-    grid := Grid[T]{
-        w: w,
-        h: h,
-		empty: empty,
-        cell: make([]T, w * h),
-    }
-
-	for i := 0; i < w * h; i++ {
-		grid.cell[i] = empty
-	}
-
-    return grid
-}
-
-func (this Grid[T]) Get(x, y int) T {
-    if this.Contains(x, y) {
-        return this.cell[this.offset(x, y)]
-    }
-    return this.empty
-}
-
-func (this *Grid[T]) Set(x, y int, value T) {
-    this.cell[this.offset(x, y)] = value
-}
-
-func (this Grid[T]) Width() int {
-    return this.w
-}
-
-func (this Grid[T]) Height() int {
-    return this.h
-}
-
-func (this Grid[T]) offset(x, y int) int {
-    return this.w * y + x
-}
-
-func (this Grid[T]) Contains(x, y int) bool {
-    return x >= 0 && y >= 0 && x < this.w && y < this.h
 }
@@ -2,7 +2,12 @@ package main
 
 import (
 	"advent-of-code/aoc"
+	"advent-of-code/aoc/explore"
+	"advent-of-code/aoc/sim"
+	"flag"
 	"fmt"
+	"os"
+	"sort"
 )
 
 type Vec2 struct {
@@ -30,49 +35,108 @@ var directions = [][]Vec2{
 
 type ElfMap map[Vec2]bool
 
+var interactive = flag.Bool("explore", false, "step through the rounds interactively")
+
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	if *interactive {
+		aoc.RefuseJSON("-explore")
+		exploreRounds(lines)
+		return
+	}
+
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
+}
+
+// Elves is the simulation of the elves spreading out, one round per step.
+type Elves struct {
+	elfMap ElfMap
+	round  int
 }
 
 func part1(lines []string) int {
-	current := parseInput(lines)
-	//current.Print()
-
-	for round := 0; round < 10; round++ {
-		next, changed := step(current, round%len(directions))
-		//fmt.Println("After round", round+1)
-		if !changed {
-			//fmt.Println("No more changes")
-			break
-		}
-		//next.Print()
-		current = next
-	}
+	elves := &Elves{parseInput(lines), 0}
+	//elves.elfMap.Print()
 
-	min, max := current.BoundingBox()
-	total := (max.x - min.x + 1) * (max.y - min.y + 1)
+	sim.Run(elves, 10)
 
-	return total - len(current)
+	return elves.EmptyGround()
 }
 
 func part2(lines []string) int {
-	current := parseInput(lines)
-	//current.Print()
-
-	for round := 0; ; round++ {
-		next, changed := step(current, round%len(directions))
-		//fmt.Println("After round", round+1)
-		if !changed {
-			//fmt.Println("No more changes")
-			return round + 1
+	elves := &Elves{parseInput(lines), 0}
+	//elves.elfMap.Print()
+
+	rounds, _ := sim.RunUntilStable(elves, 0)
+	return rounds
+}
+
+func exploreRounds(lines []string) {
+	elves := &Elves{parseInput(lines), 0}
+
+	explorer := explore.New[Elves](elves)
+	explorer.AddCondition("empty", "N: until there are at least N empty ground tiles", func(args []int) bool {
+		return len(args) > 0 && elves.EmptyGround() >= args[0]
+	})
+	aoc.CheckErr(explorer.Run(os.Stdin, os.Stdout))
+}
+
+func (this *Elves) Step() bool {
+	next, changed := step(this.elfMap, this.round%len(directions))
+	//fmt.Println("After round", this.round+1)
+	this.elfMap = next
+	this.round++
+	return changed
+}
+
+// Fingerprint lists the elves in reading order, along with which direction
+// is considered first next round.
+func (this *Elves) Fingerprint() string {
+	positions := make([]Vec2, 0, len(this.elfMap))
+	for pos := range this.elfMap {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].y != positions[j].y {
+			return positions[i].y < positions[j].y
 		}
-		//next.Print()
-		current = next
+		return positions[i].x < positions[j].x
+	})
+	return fmt.Sprint(this.round%len(directions), positions)
+}
+
+func (this *Elves) Bounds() (minX, minY, maxX, maxY int) {
+	min, max := this.elfMap.BoundingBox()
+	return min.x - 1, min.y - 1, max.x + 1, max.y + 1
+}
+
+func (this *Elves) Cell(x, y int) rune {
+	if this.elfMap[Vec2{x, y}] {
+		return '#'
 	}
+	return '.'
+}
+
+func (this *Elves) Status() string {
+	return fmt.Sprintf("round %d  empty ground %d", this.round, this.EmptyGround())
+}
+
+// EmptyGround counts the tiles in the bounding box with no elf.
+func (this *Elves) EmptyGround() int {
+	min, max := this.elfMap.BoundingBox()
+	return (max.x-min.x+1)*(max.y-min.y+1) - len(this.elfMap)
+}
+
+// Each round builds a new ElfMap, so a snapshot can share it.
+func (this *Elves) Snapshot() Elves {
+	return *this
+}
+
+func (this *Elves) Restore(snapshot Elves) {
+	*this = snapshot
 }
 
 func step(current ElfMap, heading int) (ElfMap, bool) {
@@ -2,7 +2,12 @@ package main
 
 import (
 	"advent-of-code/aoc"
+	"advent-of-code/aoc/explore"
+	"advent-of-code/aoc/mathx"
+	"advent-of-code/aoc/sim"
+	"flag"
 	"fmt"
+	"os"
 )
 
 type Wind uint8
@@ -23,76 +28,183 @@ type Vec2 struct {
 	x, y int8
 }
 
+// Valley is the blizzards as they move, one minute per step.
+type Valley struct {
+	blizzards *Blizzards
+	minute    int
+}
+
+// Expedition is everywhere the expedition could be, one minute per step.
+type Expedition struct {
+	blizzards        *Blizzards
+	startMinute      int
+	minute           int
+	startPos, endPos Vec2
+	locations        map[Vec2]bool
+	arrived          bool
+}
+
+var interactive = flag.Bool("explore", false, "step through the blizzards interactively")
+
 func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
-	fmt.Println(part2(lines))
+	if *interactive {
+		aoc.RefuseJSON("-explore")
+		exploreValley(lines)
+		return
+	}
+
+	aoc.PrintAnswer(1, part1(lines))
+	aoc.PrintAnswer(2, part2(lines))
+}
+
+// The blizzards return to their starting layout every lcm(w, h) minutes, so
+// each distinct map only needs to be built once.
+type Blizzards struct {
+	period int
+	maps   []*Map
 }
 
 func part1(lines []string) int {
-	m := parseMap(lines)
+	blizzards := newBlizzards(parseMap(lines))
 
 	startPos := Vec2{ 0, -1 }
-	endPos := Vec2{ int8(m.Width() - 1), int8(m.Height()) }
-
-	minutes, _ := run(m, startPos, endPos)
+	endPos := Vec2{ int8(blizzards.Width() - 1), int8(blizzards.Height()) }
 
-	return minutes
+	return run(blizzards, 0, startPos, endPos)
 }
 
 func part2(lines []string) int {
-	m := parseMap(lines)
+	blizzards := newBlizzards(parseMap(lines))
 
 	startPos := Vec2{ 0, -1 }
-	endPos := Vec2{ int8(m.Width() - 1), int8(m.Height()) }
+	endPos := Vec2{ int8(blizzards.Width() - 1), int8(blizzards.Height()) }
 
 	totalMinutes := 0
 
 	for i := 0; i < 3; i++ {
-		minutes, nextM := run(m, startPos, endPos)
-		totalMinutes += minutes
+		totalMinutes += run(blizzards, totalMinutes, startPos, endPos)
 		startPos, endPos = endPos, startPos
-		m = nextM
 	}
 
 	return totalMinutes
 }
 
-func run(current *Map, startPos, endPos Vec2) (int, *Map) {
-	locations := make(map[Vec2]bool)
-	locations[ startPos ] = true
-	minute := 0
-
-	moves := [...]Vec2{ {0,0}, {-1,0}, {1,0}, {0,1}, {0,-1} }
-
-	for len(locations) > 0 {
-		minute++
+func run(blizzards *Blizzards, startMinute int, startPos, endPos Vec2) int {
+	expedition := &Expedition{
+		blizzards:   blizzards,
+		startMinute: startMinute,
+		startPos:    startPos,
+		endPos:      endPos,
+		locations:   map[Vec2]bool{startPos: true},
+	}
+	sim.RunUntilStable(expedition, 0)
+	return expedition.minute
+}
 
-		next := step(current)
-		//next.Print()
-		nextLocations := make(map[Vec2]bool)
+var moves = [...]Vec2{ {0,0}, {-1,0}, {1,0}, {0,1}, {0,-1} }
 
-		for location := range locations {
-			for _, move := range moves {
-				nextLocation := location.Add(move)
-				if nextLocation == endPos {
-					return minute, next
-				}
-				if nextLocation == startPos || next.canMove(nextLocation) {
-					//fmt.Printf("Moving from %v to %v\n", location, nextLocation)
-					nextLocations[nextLocation] = true
-				}
+// Step spreads out to everywhere reachable in the next minute, reporting
+// whether there was anywhere left to go.
+func (this *Expedition) Step() bool {
+	if this.arrived || len(this.locations) == 0 {
+		return false
+	}
+	this.minute++
+
+	next := this.blizzards.At(this.startMinute + this.minute)
+	//next.Print()
+	nextLocations := make(map[Vec2]bool)
+
+	for location := range this.locations {
+		for _, move := range moves {
+			nextLocation := location.Add(move)
+			if nextLocation == this.endPos {
+				this.arrived = true
+				return true
+			}
+			if nextLocation == this.startPos || next.canMove(nextLocation) {
+				//fmt.Printf("Moving from %v to %v\n", location, nextLocation)
+				nextLocations[nextLocation] = true
 			}
 		}
+	}
+
+	this.locations = nextLocations
+
+	//fmt.Printf("Minute %d: %d locations\n", this.minute, len(this.locations))
+	return true
+}
+
+func exploreValley(lines []string) {
+	valley := &Valley{newBlizzards(parseMap(lines)), 0}
+
+	explorer := explore.New[int](valley)
+	explorer.AddCondition("clear", "X Y: until there is no blizzard at X,Y", func(args []int) bool {
+		return len(args) == 2 && valley.Cell(args[0], args[1]) == '.'
+	})
+	aoc.CheckErr(explorer.Run(os.Stdin, os.Stdout))
+}
 
-		current = next
-		locations = nextLocations
+func (this *Valley) Step() bool {
+	this.minute++
+	return true
+}
+
+func (this *Valley) Snapshot() int {
+	return this.minute
+}
 
-		//fmt.Printf("Minute %d: %d locations\n", minute, len(locations))
+func (this *Valley) Restore(minute int) {
+	this.minute = minute
+}
+
+// The valley is drawn with its walls, which are outside the map.
+func (this *Valley) Bounds() (minX, minY, maxX, maxY int) {
+	return -1, -1, this.blizzards.Width(), this.blizzards.Height()
+}
+
+func (this *Valley) Cell(x, y int) rune {
+	w, h := this.blizzards.Width(), this.blizzards.Height()
+	m := this.blizzards.At(this.minute)
+
+	switch {
+	case x == 0 && y == -1, x == w-1 && y == h:
+		return '.' // the way in and out
+	case !m.Contains(x, y):
+		return '#'
+	default:
+		return windChar(m.Get(x, y))
+	}
+}
+
+func (this *Valley) Status() string {
+	return fmt.Sprintf("minute %d  period %d", this.minute, this.blizzards.period)
+}
+
+func newBlizzards(start *Map) *Blizzards {
+	return &Blizzards{
+		period: mathx.Lcm(start.Width(), start.Height()),
+		maps:   []*Map{ start },
 	}
-	return minute, current
+}
+
+func (this *Blizzards) At(minute int) *Map {
+	minute %= this.period
+	for len(this.maps) <= minute {
+		this.maps = append(this.maps, step(this.maps[len(this.maps)-1]))
+	}
+	return this.maps[minute]
+}
+
+func (this *Blizzards) Width() int {
+	return this.maps[0].Width()
+}
+
+func (this *Blizzards) Height() int {
+	return this.maps[0].Height()
 }
 
 func makeMap(w, h int) *Map {
@@ -124,26 +236,32 @@ func (this *Map) AddWind(x, y int, wind Wind) {
 	this.Set(x, y, wind | this.Get(x, y))
 }
 
+// The direction each wind blows in.
+var windDirs = []struct {
+	wind Wind
+	dir  aoc.Dir
+}{
+	{ North, aoc.North },
+	{ South, aoc.South },
+	{ West, aoc.West },
+	{ East, aoc.East },
+}
+
 func step(current *Map) *Map {
 	w := current.Width()
 	h := current.Height()
 	next := makeMap(current.Width(), current.Height())
+	valley := aoc.NewTorus(w, h)
 
 	for y := 0; y < h; y++ {
 		for x := 0; x < w; x++ {
 			wind := current.Get(x, y)
 
-			if wind & North == North {
-				next.AddWind(x, (y + h - 1) % h, North)
-			}
-			if wind & South == South {
-				next.AddWind(x, (y + h + 1) % h, South)
-			}
-			if wind & West == West {
-				next.AddWind((x + w - 1) % w, y, West)
-			}
-			if wind & East == East {
-				next.AddWind((x + w + 1) % w, y, East)
+			for _, wd := range windDirs {
+				if wind & wd.wind == wd.wind {
+					nx, ny, _, _ := valley.Step(x, y, wd.dir)
+					next.AddWind(nx, ny, wd.wind)
+				}
 			}
 		}
 	}
@@ -158,25 +276,24 @@ func (this *Map) Print() {
 	for y := 0; y < h; y++ {
 		for x := 0; x < w; x++ {
 
-			wind := this.Get(x, y)
-			var char rune
-
-			switch wind {
-			case North: char = '^'
-			case South: char = 'V'
-			case East:  char = '>'
-			case West:  char = '<'
-			case None:  char = '.'
-			default: char = rune('0' + countBits(wind))
-			}
-
-			fmt.Printf("%c", char)
+			fmt.Printf("%c", windChar(this.Get(x, y)))
 		}
 		fmt.Println()
 	}
 	fmt.Println()
 }
 
+func windChar(wind Wind) rune {
+	switch wind {
+	case North: return '^'
+	case South: return 'v'
+	case East:  return '>'
+	case West:  return '<'
+	case None:  return '.'
+	default: return rune('0' + countBits(wind))
+	}
+}
+
 func (this *Map) canMove(p Vec2) bool {
 	x := int(p.x)
 	y := int(p.y)
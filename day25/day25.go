@@ -9,7 +9,7 @@ func main() {
 	filename := aoc.GetFilename()
 	lines := aoc.GetInputLines(filename)
 
-	fmt.Println(part1(lines))
+	aoc.PrintAnswer(1, part1(lines))
 }
 
 func part1(lines []string) string {